@@ -0,0 +1,49 @@
+package configfile_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestArgsReader(t *testing.T) {
+	c := configfile.NewArgsReader([]string{
+		"serve",
+		"--addr=:9000",
+		"--redis-addr", "localhost:6379",
+		"-debug",
+		"--no-cache",
+		"--typo", "1",
+		"--",
+		"--ignored=1",
+	}).Fallback(configfile.NewReader("testdata"))
+
+	assert.Equal(t, ":9000", c.String("addr"))
+	assert.Equal(t, "localhost:6379", c.String("redis_addr"))
+	assert.True(t, c.Bool("debug"))
+	assert.False(t, c.BoolDefault("cache", true))
+	assert.Equal(t, "", c.String("ignored"))
+	assert.Equal(t, 9, c.Int("data3"))
+
+	assert.Equal(t, []string{"typo"}, c.UnknownArgs())
+}
+
+func TestArgsReaderValue(t *testing.T) {
+	c := configfile.NewArgsReader([]string{
+		"--offset", "-1",
+		"--ratio", "-0.5",
+		"--verbose", "serve",
+		"--debug", "--port", "8080",
+		"--quiet=true", "run",
+	})
+
+	assert.Equal(t, -1, c.Int("offset"))
+	assert.Equal(t, -0.5, c.Float64("ratio"))
+	assert.Equal(t, "serve", c.String("verbose"), "next non flag argument is the value")
+	assert.True(t, c.Bool("debug"))
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.True(t, c.Bool("quiet"))
+	assert.Empty(t, c.UnknownArgs())
+}
@@ -0,0 +1,28 @@
+package configfile
+
+import "github.com/acoshift/configfile/internal/reader"
+
+// CacheStatus is the state of a source cached with Cache
+type CacheStatus = reader.CacheStatus
+
+// Cache keeps last known good values of this reader's source in filename,
+// values in the file are served when the source is unreachable,
+// at startup or during outages, so Must accessors do not panic on a remote blip.
+// All keys of the source are fetched at once and written to the file by Reload
+// and remote watch updates, values read on demand are written by the next Reload.
+// The file holds plain values, keep it on a private volume
+func (r *Reader) Cache(filename string) *Reader {
+	r.r = reader.NewCached(r.r, filename)
+	return r
+}
+
+// CacheStatus returns status of the first cached source in the chain,
+// ok is false when no source is cached
+func (r *Reader) CacheStatus() (status CacheStatus, ok bool) {
+	for p := r; p != nil; p = p.fallback {
+		if c, ok := p.r.(*reader.Cached); ok {
+			return c.Status(), true
+		}
+	}
+	return CacheStatus{}, false
+}
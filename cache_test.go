@@ -0,0 +1,143 @@
+package configfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestCache(t *testing.T) {
+	s, srv := newFakeConfigServer(t)
+	s.set("/myapp.json", `{"port": 8080, "redis": {"addr": "localhost:6379"}}`)
+	fn := filepath.Join(t.TempDir(), "myapp.cache")
+	opt := configfile.HTTPOptions{Header: authHeader, Retries: -1}
+
+	c := configfile.NewHTTPReaderWithOptions(srv.URL+"/myapp.json", opt).Cache(fn)
+	assert.Equal(t, 8080, c.MustInt("port"))
+	status, ok := c.CacheStatus()
+	assert.True(t, ok)
+	assert.False(t, status.Stale)
+	assert.False(t, status.UpdatedAt.IsZero())
+
+	stats, err := os.Stat(fn)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), stats.Mode().Perm())
+
+	t.Run("Startup", func(t *testing.T) {
+		atomic.StoreInt32(&s.fails, 100)
+		defer atomic.StoreInt32(&s.fails, 0)
+
+		c := configfile.NewHTTPReaderWithOptions(srv.URL+"/myapp.json", opt).Cache(fn)
+		assert.NotPanics(t, func() { c.MustInt("port") })
+		assert.Equal(t, 8080, c.Int("port"))
+		assert.Equal(t, "localhost:6379", c.Sub("redis").String("addr"))
+		assert.Equal(t, []string{"port", "redis.addr"}, c.Keys())
+
+		status, _ := c.CacheStatus()
+		assert.True(t, status.Stale)
+		assert.False(t, status.StaleSince.IsZero())
+		assert.Error(t, status.Err)
+		assert.Equal(t, 8080, configfile.Get[int](c, "port"))
+	})
+
+	t.Run("Outage", func(t *testing.T) {
+		atomic.StoreInt32(&s.fails, 100)
+		assert.Error(t, c.Reload())
+		assert.Equal(t, 8080, c.Int("port"))
+		status, _ := c.CacheStatus()
+		assert.True(t, status.Stale)
+
+		atomic.StoreInt32(&s.fails, 0)
+		s.set("/myapp.json", `{"port": 8081}`)
+		require.NoError(t, c.Reload())
+		assert.Equal(t, 8081, c.Int("port"))
+		status, _ = c.CacheStatus()
+		assert.False(t, status.Stale)
+	})
+
+	t.Run("Updated", func(t *testing.T) {
+		atomic.StoreInt32(&s.fails, 100)
+		defer atomic.StoreInt32(&s.fails, 0)
+
+		c := configfile.NewHTTPReaderWithOptions(srv.URL+"/myapp.json", opt).Cache(fn)
+		assert.Equal(t, 8081, c.Int("port"))
+		assert.Equal(t, "", c.String("redis.addr"))
+	})
+}
+
+func TestCacheVault(t *testing.T) {
+	v, srv := newFakeVault(t)
+	v.set("secret/data/app", map[string]interface{}{"key": "a"})
+	v.set("secret/data/app/db", map[string]interface{}{"password": "pass"})
+	fn := filepath.Join(t.TempDir(), "vault.cache")
+
+	c := configfile.NewVaultReader(configfile.VaultOptions{Address: srv.URL, Token: "root", Path: "app"}).Cache(fn)
+	assert.Equal(t, "a", c.String("key"))
+	assert.Equal(t, "pass", c.String("db/password"))
+
+	// values read on demand are saved by reload, not on read
+	b, err := os.ReadFile(fn)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "db/password")
+	require.NoError(t, c.Reload())
+	b, err = os.ReadFile(fn)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "db/password")
+
+	// token revoked
+	c = configfile.NewVaultReader(configfile.VaultOptions{Address: srv.URL, Token: "revoked", Path: "app"}).Cache(fn).
+		Fallback(configfile.NewMapReader(map[string]string{"other": "b"}))
+	assert.Equal(t, "a", c.MustString("key"))
+	assert.Equal(t, "pass", c.MustString("db/password"))
+	assert.Equal(t, "b", c.String("other"))
+
+	status, ok := c.CacheStatus()
+	assert.True(t, ok)
+	assert.True(t, status.Stale)
+
+	_, ok = configfile.NewEnvReader().CacheStatus()
+	assert.False(t, ok)
+}
+
+func TestCacheWatch(t *testing.T) {
+	fc, srv := newFakeConsul(t)
+	fc.set("myapp/port", "8080")
+	fn := filepath.Join(t.TempDir(), "consul.cache")
+
+	c := configfile.NewConsulReader(configfile.ConsulOptions{
+		Address:  srv.URL,
+		Token:    "token",
+		Prefix:   "myapp",
+		WaitTime: 50 * time.Millisecond,
+	}).Cache(fn)
+	assert.Equal(t, 8080, c.Int("port"))
+
+	stop := c.WatchRemote()
+	defer stop()
+
+	fc.mu.Lock()
+	fc.down = true
+	fc.mu.Unlock()
+
+	assert.Eventually(t, func() bool {
+		status, _ := c.CacheStatus()
+		return status.Stale && status.Err != nil
+	}, 2*time.Second, 10*time.Millisecond, "watch failure must mark cache stale")
+	assert.Equal(t, 8080, c.Int("port"))
+
+	fc.mu.Lock()
+	fc.down = false
+	fc.mu.Unlock()
+
+	assert.Eventually(t, func() bool {
+		status, _ := c.CacheStatus()
+		return !status.Stale
+	}, 5*time.Second, 10*time.Millisecond, "recovered watch must clear stale")
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/acoshift/configfile"
+)
+
+const usage = `usage: configfile <command> [arguments]
+
+commands:
+  convert    convert config between dir, yaml, json and .env formats
+  keygen     generate key file for encrypted values
+  encrypt    encrypt a value for reading with AES-GCM decrypter
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "convert":
+		err = convert(os.Args[2:])
+	case "keygen":
+		err = keygen()
+	case "encrypt":
+		err = encrypt(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "configfile: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "configfile: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func convert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	from := fs.String("from", "", "source format (dir, yaml, json, env), detect from src if empty")
+	to := fs.String("to", "", "destination format (dir, yaml, json, env), detect from dst if empty")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: configfile convert [-from format] [-to format] <src> <dst>")
+		fmt.Fprintln(fs.Output(), "\nuse - as dst to write to stdout")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	src, dst := fs.Arg(0), fs.Arg(1)
+
+	srcFormat, err := format(*from, src)
+	if err != nil {
+		return err
+	}
+	data, err := configfile.ReadAll(src, srcFormat)
+	if err != nil {
+		return err
+	}
+
+	if dst == "-" {
+		if *to == "" {
+			return fmt.Errorf("-to is required when writing to stdout")
+		}
+		dstFormat, err := configfile.ParseFormat(*to)
+		if err != nil {
+			return err
+		}
+		return configfile.Encode(os.Stdout, dstFormat, data)
+	}
+
+	dstFormat, err := format(*to, dst)
+	if err != nil {
+		return err
+	}
+	return configfile.WriteAll(dst, dstFormat, data)
+}
+
+func format(name, path string) (configfile.Format, error) {
+	if name != "" {
+		return configfile.ParseFormat(name)
+	}
+	return configfile.DetectFormat(path)
+}
+
+func keygen() error {
+	key, err := configfile.GenerateAESKey()
+	if err != nil {
+		return err
+	}
+	fmt.Println(key)
+	return nil
+}
+
+func encrypt(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	keyFile := fs.String("key", "", "key file generated by keygen")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: configfile encrypt -key file [value]")
+		fmt.Fprintln(fs.Output(), "\nvalue is read from stdin if not given")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *keyFile == "" || fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	c, err := configfile.NewAESGCMFromFile(*keyFile)
+	if err != nil {
+		return err
+	}
+
+	var value []byte
+	if fs.NArg() == 1 {
+		value = []byte(fs.Arg(0))
+	} else {
+		value, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+	}
+
+	s, err := c.Encrypt(value)
+	if err != nil {
+		return err
+	}
+	fmt.Println(s)
+	return nil
+}
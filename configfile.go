@@ -2,10 +2,15 @@ package configfile
 
 import (
 	"encoding/base64"
+	"errors"
+	"flag"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strconv"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -13,6 +18,8 @@ import (
 	"github.com/acoshift/configfile/internal/reader"
 )
 
+// LoadDotEnv loads .env files into env, existing env is not overridden,
+// see LoadDotEnvFlow for loading layered .env files
 func LoadDotEnv(filename ...string) error {
 	return godotenv.Load(filename...)
 }
@@ -24,20 +31,42 @@ func NewReader(base string) *Reader {
 		if stats.IsDir() {
 			return NewDirReader(base).Fallback(NewEnvReader())
 		}
-		return NewYAMLReader(base).Fallback(NewEnvReader())
+		return newFileReader(base).Fallback(NewEnvReader())
 	}
 	return NewEnvReader()
 }
 
+// newFileReader creates reader from file extension, default to yaml
+func newFileReader(filename string) *Reader {
+	switch filepath.Ext(filename) {
+	case ".json":
+		return NewJSONReader(filename)
+	case ".env":
+		return NewDotEnvReader(filename)
+	}
+	return NewYAMLReader(filename)
+}
+
 // NewDirReader creates new config dir reader
 func NewDirReader(base string) *Reader {
-	return &Reader{r: reader.NewDir(base)}
+	return &Reader{r: reader.NewDir(base), norm: NormalizeTrimNewline}
+}
+
+// NewFSReader creates new config reader from fs.FS, each file is a config like NewDirReader,
+// useful for defaults embedded with embed.FS
+func NewFSReader(fsys fs.FS) *Reader {
+	return &Reader{r: reader.NewFS(fsys), norm: NormalizeTrimNewline}
+}
+
+// NewDirSnapshotReader creates new config dir reader that loads the whole dir into memory,
+// call Reload to swap in the current content of dir at once
+func NewDirSnapshotReader(base string) *Reader {
+	return &Reader{r: reader.NewDirSnapshot(base), norm: NormalizeTrimNewline}
 }
 
 // NewYAMLReader creates new yaml reader from file
 func NewYAMLReader(filename string) *Reader {
-	fs, _ := os.Open(filename)
-	return NewYAMLReaderFromReader(fs)
+	return &Reader{r: reader.NewYAMLFile(filename)}
 }
 
 // NewYAMLReaderFromReader creates new yaml reader from io.Reader
@@ -45,6 +74,69 @@ func NewYAMLReaderFromReader(r io.Reader) *Reader {
 	return &Reader{r: reader.NewYAML(r)}
 }
 
+// NewJSONReader creates new json reader from file
+func NewJSONReader(filename string) *Reader {
+	return &Reader{r: reader.NewJSONFile(filename)}
+}
+
+// NewJSONReaderFromReader creates new json reader from io.Reader
+func NewJSONReaderFromReader(r io.Reader) *Reader {
+	return &Reader{r: reader.NewJSON(r)}
+}
+
+// NewDotEnvReader creates new reader from .env file,
+// unlike LoadDotEnv it does not modify process env
+func NewDotEnvReader(filename string) *Reader {
+	return &Reader{r: reader.NewDotEnvFile(filename)}
+}
+
+// NewDotEnvReaderFromReader creates new .env reader from io.Reader
+func NewDotEnvReaderFromReader(r io.Reader) *Reader {
+	return &Reader{r: reader.NewDotEnv(r)}
+}
+
+// NewFlagReader creates new reader from flag set,
+// only flags that explicitly set are found,
+// a config name is looked up as is then as lower kebab case (redis_addr => redis-addr).
+// If fs is nil, flag.CommandLine is used
+func NewFlagReader(fs *flag.FlagSet) *Reader {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	return &Reader{r: reader.NewFlag(fs)}
+}
+
+// RegisterFlags defines string flags for config names that not yet defined in fs,
+// use with NewFlagReader to allow override any config from command line.
+// If fs is nil, flag.CommandLine is used
+func RegisterFlags(fs *flag.FlagSet, names ...string) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	for _, name := range names {
+		n := reader.FlagName(name)
+		if fs.Lookup(n) != nil {
+			continue
+		}
+		fs.String(n, "", "override config "+name)
+	}
+}
+
+// NewArgsReader creates new reader from command line arguments,
+// supports --key=value, --key value, --key (true) and --no-key (false),
+// positional arguments and arguments after "--" are ignored.
+// A flag without "=" takes the next argument as value unless it starts with "-"
+// and is not a number, so "--offset -1" is -1 but "--verbose serve" is "serve",
+// use --verbose=true or put boolean flags before other flags or "--"
+func NewArgsReader(args []string) *Reader {
+	return &Reader{r: reader.NewArgs(args)}
+}
+
+// NewMapReader creates new reader from map, the map is copied
+func NewMapReader(m map[string]string) *Reader {
+	return &Reader{r: reader.NewMap(m)}
+}
+
 // NewEnvReader creates new env reader
 func NewEnvReader() *Reader {
 	return &Reader{r: reader.NewEnv()}
@@ -56,8 +148,25 @@ type intlReader interface {
 
 // Reader is the config reader
 type Reader struct {
-	r        intlReader
-	fallback *Reader
+	r         intlReader
+	fallback  *Reader
+	decrypter Decrypter
+	norm      Normalization
+	empty     EmptyPolicy
+
+	mu         sync.Mutex
+	w          *watcher
+	overlay    atomic.Pointer[reader.Map]
+	overrides  map[string][]override
+	overrideID uint64
+
+	// known holds names that read or declared, for Strict
+	known  sync.Map
+	parent *Reader
+	prefix string
+
+	// children are readers from Sub that have watcher, notified when r changed
+	children []*Reader
 }
 
 func (r *Reader) Fallback(f *Reader) *Reader {
@@ -65,10 +174,67 @@ func (r *Reader) Fallback(f *Reader) *Reader {
 	return r
 }
 
+type reloader interface {
+	Reload() error
+}
+
+// Reload reloads all reloadable sources in the chain,
+// sources that not support reload are unchanged.
+// Subscribers registered with OnChange get notified when values change
+func (r *Reader) Reload() error {
+	var errs []error
+	for p := r; p != nil; p = p.fallback {
+		if rd, ok := p.r.(reloader); ok {
+			if err := rd.Reload(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	r.changed()
+	return errors.Join(errs...)
+}
+
+// UnknownArgs returns flags given to args readers in the chain
+// that never read by any accessor, call after all configs are read
+func (r *Reader) UnknownArgs() []string {
+	var xs []string
+	for p := r; p != nil; p = p.fallback {
+		if a, ok := p.r.(*reader.Args); ok {
+			xs = append(xs, a.Unknown()...)
+		}
+	}
+	return xs
+}
+
 func (r *Reader) read(name string) ([]byte, error) {
+	b, err := r.lookup(name, r.empty)
+	if err == nil && len(b) == 0 && r.empty == EmptyAsError {
+		return nil, ErrEmpty
+	}
+	return b, err
+}
+
+// lookup reads name from the chain, empty values are skipped when policy is EmptyAsUnset
+func (r *Reader) lookup(name string, empty EmptyPolicy) ([]byte, error) {
+	r.record(name)
+
+	if b, ok := r.overridden(name); ok && (len(b) > 0 || empty != EmptyAsUnset) {
+		return b, nil
+	}
+
 	b, err := r.r.Read(name)
+	if err == nil {
+		b = r.norm.apply(b)
+		if len(b) == 0 && empty == EmptyAsUnset {
+			err = errUnset
+		}
+	}
 	if err != nil && r.fallback != nil {
-		b, err = r.fallback.read(name)
+		b, err = r.fallback.lookup(name, empty)
+	}
+	if err == nil && r.decrypter != nil {
+		b, err = decrypt(r.decrypter, b)
 	}
 	return b, err
 }
@@ -83,7 +249,7 @@ func (r *Reader) readString(name string) (string, error) {
 
 func (r *Reader) readInt(name string) (int, error) {
 	s, err := r.readString(name)
-	if err != nil {
+	if err != nil || r.zero(s) {
 		return 0, err
 	}
 	return strconv.Atoi(s)
@@ -91,7 +257,7 @@ func (r *Reader) readInt(name string) (int, error) {
 
 func (r *Reader) readInt64(name string) (int64, error) {
 	s, err := r.readString(name)
-	if err != nil {
+	if err != nil || r.zero(s) {
 		return 0, err
 	}
 	return strconv.ParseInt(s, 10, 64)
@@ -99,19 +265,15 @@ func (r *Reader) readInt64(name string) (int64, error) {
 
 func (r *Reader) readFloat32(name string) (float32, error) {
 	s, err := r.readString(name)
-	if err != nil {
+	if err != nil || r.zero(s) {
 		return 0, err
 	}
-	f, err := strconv.ParseFloat(s, 32)
-	if err != nil {
-		return 0, err
-	}
-	return float32(f), nil
+	return parseFloat32(s)
 }
 
 func (r *Reader) readFloat64(name string) (float64, error) {
 	s, err := r.readString(name)
-	if err != nil {
+	if err != nil || r.zero(s) {
 		return 0, err
 	}
 	return strconv.ParseFloat(s, 64)
@@ -119,24 +281,15 @@ func (r *Reader) readFloat64(name string) (float64, error) {
 
 func (r *Reader) readBool(name string) (bool, error) {
 	s, err := r.readString(name)
-	if err != nil {
+	if err != nil || r.zero(s) {
 		return false, err
 	}
-	if s == "" {
-		return false, io.EOF
-	}
-	if s == "0" {
-		return false, nil
-	}
-	if strings.ToLower(s) == "false" {
-		return false, nil
-	}
-	return true, nil
+	return parseBool(s)
 }
 
 func (r *Reader) readDuration(name string) (time.Duration, error) {
 	s, err := r.readString(name)
-	if err != nil {
+	if err != nil || r.zero(s) {
 		return 0, err
 	}
 	return time.ParseDuration(s)
@@ -474,6 +474,16 @@ func TestYAMLReader(t *testing.T) {
 	testReader(t, configfile.NewReader("testdata/config.yaml"))
 }
 
+func TestJSONReader(t *testing.T) {
+	testReader(t, configfile.NewJSONReader("testdata/config.json"))
+	testReader(t, configfile.NewReader("testdata/config.json"))
+}
+
+func TestDotEnvReader(t *testing.T) {
+	testReader(t, configfile.NewDotEnvReader("testdata/config.env"))
+	testReader(t, configfile.NewReader("testdata/config.env"))
+}
+
 func TestEnvReader(t *testing.T) {
 	testReader(t, configfile.NewEnvReader())
 	testReader(t, configfile.NewReader("notexists"))
@@ -0,0 +1,29 @@
+// Package configtest provides helpers for testing code that reads config with configfile
+package configtest
+
+import (
+	"testing"
+
+	"github.com/acoshift/configfile"
+)
+
+// Override sets value of key on top of r for the rest of the test,
+// the override is removed at cleanup, in any order with other overrides of key.
+// Tests that override the same key of a shared reader must not run in parallel,
+// use With instead
+func Override(t testing.TB, r *configfile.Reader, key, value string) {
+	t.Helper()
+
+	t.Cleanup(r.Override(key, value))
+}
+
+// With returns new reader that reads values from m then r,
+// r is unchanged so parallel tests can use their own values
+func With(r *configfile.Reader, m map[string]string) *configfile.Reader {
+	return r.With(m)
+}
+
+// New creates new reader from map
+func New(m map[string]string) *configfile.Reader {
+	return configfile.NewMapReader(m)
+}
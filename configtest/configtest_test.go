@@ -0,0 +1,72 @@
+package configtest_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acoshift/configfile"
+	"github.com/acoshift/configfile/configtest"
+)
+
+func TestOverride(t *testing.T) {
+	c := configfile.NewYAMLReader("../testdata/config.yaml")
+
+	t.Run("Override", func(t *testing.T) {
+		configtest.Override(t, c, "data3", "10")
+		configtest.Override(t, c, "extra", "1")
+		assert.Equal(t, 10, c.Int("data3"))
+		assert.Equal(t, "1", c.String("extra"))
+	})
+
+	assert.Equal(t, 9, c.Int("data3"))
+	assert.Equal(t, "", c.String("extra"))
+}
+
+func TestOverrideSub(t *testing.T) {
+	c := configfile.NewMapReader(map[string]string{"redis.addr": "a"})
+	redis := c.Sub("redis")
+
+	t.Run("Override", func(t *testing.T) {
+		configtest.Override(t, c, "redis.addr", "b")
+		assert.Equal(t, "b", redis.String("addr"))
+		assert.Equal(t, "b", c.Sub("redis").String("addr"))
+	})
+
+	assert.Equal(t, "a", redis.String("addr"))
+}
+
+func TestWith(t *testing.T) {
+	c := configfile.NewMapReader(map[string]string{"workers": "1", "redis.addr": "a"})
+
+	for _, tc := range []struct {
+		name    string
+		workers int
+		addr    string
+	}{
+		{"A", 2, "b"},
+		{"B", 3, "c"},
+		{"C", 4, "d"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := configtest.With(c, map[string]string{
+				"workers":    strconv.Itoa(tc.workers),
+				"redis.addr": tc.addr,
+			})
+			for i := 0; i < 100; i++ {
+				assert.Equal(t, tc.workers, r.Int("workers"))
+				assert.Equal(t, tc.addr, r.Sub("redis").String("addr"))
+			}
+			assert.Equal(t, 1, c.Int("workers"))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	c := configtest.New(map[string]string{"port": "8080"})
+	assert.Equal(t, 8080, c.Int("port"))
+}
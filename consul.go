@@ -0,0 +1,23 @@
+package configfile
+
+import (
+	"os"
+
+	"github.com/acoshift/configfile/internal/reader"
+)
+
+// ConsulOptions configures NewConsulReader
+type ConsulOptions = reader.ConsulOptions
+
+// NewConsulReader creates new reader from consul kv, keys under opt.Prefix are loaded at once.
+// Empty Address and Token are taken from CONSUL_HTTP_ADDR and CONSUL_HTTP_TOKEN env.
+// Call Reload to load keys again, or WatchRemote to receive changes with blocking queries
+func NewConsulReader(opt ConsulOptions) *Reader {
+	if opt.Address == "" {
+		opt.Address = os.Getenv("CONSUL_HTTP_ADDR")
+	}
+	if opt.Token == "" {
+		opt.Token = os.Getenv("CONSUL_HTTP_TOKEN")
+	}
+	return &Reader{r: reader.NewConsul(opt)}
+}
@@ -0,0 +1,190 @@
+package configfile_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+// fakeConsul mimics consul kv http api with blocking queries
+type fakeConsul struct {
+	mu      sync.Mutex
+	kv      map[string]string
+	index   uint64
+	changed chan struct{}
+	down    bool
+}
+
+func newFakeConsul(t *testing.T) (*fakeConsul, *httptest.Server) {
+	c := &fakeConsul{kv: make(map[string]string), index: 1, changed: make(chan struct{})}
+	srv := httptest.NewServer(c)
+	t.Cleanup(srv.Close)
+	return c, srv
+}
+
+func (c *fakeConsul) set(key, value string) {
+	c.mu.Lock()
+	c.kv[key] = value
+	c.index++
+	close(c.changed)
+	c.changed = make(chan struct{})
+	c.mu.Unlock()
+}
+
+func (c *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Consul-Token") != "token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	c.mu.Lock()
+	index, changed, down := c.index, c.changed, c.down
+	c.mu.Unlock()
+	if down {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if p := r.URL.Query().Get("index"); p != "" {
+		wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+		if i, _ := strconv.ParseUint(p, 10, 64); i >= index {
+			select {
+			case <-changed:
+			case <-time.After(wait):
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	var kvs []map[string]interface{}
+	for k, v := range c.kv {
+		if strings.HasPrefix(k, prefix) {
+			kvs = append(kvs, map[string]interface{}{"Key": k, "Value": []byte(v), "ModifyIndex": c.index})
+		}
+	}
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(c.index, 10))
+	if len(kvs) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(kvs)
+}
+
+func TestConsulReader(t *testing.T) {
+	fc, srv := newFakeConsul(t)
+	fc.set("myapp/port", "8080")
+	fc.set("myapp/redis/addr", "localhost:6379")
+	fc.set("myapp/", "")
+	fc.set("other/port", "9000")
+
+	c := configfile.NewConsulReader(configfile.ConsulOptions{Address: srv.URL, Token: "token", Prefix: "myapp"}).
+		Fallback(configfile.NewMapReader(map[string]string{"debug": "true"}))
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.Equal(t, "localhost:6379", c.String("redis/addr"))
+	assert.Equal(t, "localhost:6379", c.Sub("redis").String("addr"))
+	assert.True(t, c.Bool("debug"))
+	assert.Equal(t, []string{"debug", "port", "redis/addr"}, c.Keys())
+
+	fc.set("myapp/port", "8081")
+	assert.Equal(t, 8080, c.Int("port"))
+	require.NoError(t, c.Reload())
+	assert.Equal(t, 8081, c.Int("port"))
+}
+
+func TestConsulReaderWatch(t *testing.T) {
+	fc, srv := newFakeConsul(t)
+	fc.set("myapp/port", "8080")
+
+	c := configfile.NewConsulReader(configfile.ConsulOptions{
+		Address:  srv.URL,
+		Token:    "token",
+		Prefix:   "myapp",
+		WaitTime: time.Second,
+	}).Debounce(0)
+	port := configfile.Watch(c, "port", 0)
+	assert.Equal(t, 8080, port.Load())
+
+	stop := c.WatchRemote()
+	defer stop()
+
+	ch := make(chan string, 1)
+	c.OnChange("port", func(_, new []byte) { ch <- string(new) })
+
+	time.Sleep(50 * time.Millisecond)
+	fc.set("myapp/port", "8081")
+	select {
+	case v := <-ch:
+		assert.Equal(t, "8081", v)
+	case <-time.After(2 * time.Second):
+		t.Fatal("change not notified")
+	}
+	assert.Eventually(t, func() bool { return port.Load() == 8081 }, time.Second, 10*time.Millisecond)
+}
+
+func TestConsulReaderWatchError(t *testing.T) {
+	fc, srv := newFakeConsul(t)
+	fc.set("myapp/port", "8080")
+
+	c := configfile.NewConsulReader(configfile.ConsulOptions{
+		Address:  srv.URL,
+		Token:    "token",
+		Prefix:   "myapp",
+		WaitTime: 50 * time.Millisecond,
+	})
+	errs := make(chan error, 10)
+	c.OnWatchError(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+
+	fc.mu.Lock()
+	fc.down = true
+	fc.mu.Unlock()
+
+	stop := c.WatchRemote()
+	defer stop()
+
+	select {
+	case err := <-errs:
+		assert.EqualError(t, err, "reader: consul 500 Internal Server Error")
+	case <-time.After(2 * time.Second):
+		t.Fatal("error not reported")
+	}
+	assert.Equal(t, 8080, c.Int("port"), "must serve last values")
+}
+
+func TestConsulReaderTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	c := configfile.NewConsulReader(configfile.ConsulOptions{Address: srv.URL, Timeout: 50 * time.Millisecond}).
+		Fallback(configfile.NewMapReader(map[string]string{"port": "8080"}))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 8080, c.Int("port"))
+}
+
+func TestConsulReaderUnavailable(t *testing.T) {
+	c := configfile.NewConsulReader(configfile.ConsulOptions{Address: "127.0.0.1:1", Prefix: "myapp"}).
+		Fallback(configfile.NewMapReader(map[string]string{"port": "8080"}))
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.Error(t, c.Reload())
+}
@@ -0,0 +1,262 @@
+package configfile
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+
+	"github.com/acoshift/configfile/internal/reader"
+)
+
+// Format is a config source format
+type Format string
+
+// Supported formats
+const (
+	FormatDir    Format = "dir"
+	FormatYAML   Format = "yaml"
+	FormatJSON   Format = "json"
+	FormatDotEnv Format = "env"
+)
+
+// ParseFormat parses format name
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "dir":
+		return FormatDir, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "json":
+		return FormatJSON, nil
+	case "env", "dotenv":
+		return FormatDotEnv, nil
+	}
+	return "", fmt.Errorf("configfile: unknown format %q", s)
+}
+
+// DetectFormat detects format of path,
+// an existing directory or a path without extension is FormatDir,
+// otherwise format comes from file extension
+func DetectFormat(path string) (Format, error) {
+	if stats, err := os.Stat(path); err == nil && stats.IsDir() {
+		return FormatDir, nil
+	}
+	base := filepath.Base(path)
+	if base == ".env" || strings.HasPrefix(base, ".env.") {
+		return FormatDotEnv, nil
+	}
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return FormatDir, nil
+	}
+	return ParseFormat(ext[1:])
+}
+
+// binaryPrefix marks base64 encoded values in formats that can not hold binary data
+const binaryPrefix = "base64:"
+
+func encodeValue(b []byte) string {
+	if utf8.Valid(b) && !bytes.HasPrefix(b, []byte(binaryPrefix)) {
+		return string(b)
+	}
+	return binaryPrefix + base64.StdEncoding.EncodeToString(b)
+}
+
+func decodeValue(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, binaryPrefix) {
+		return []byte(s), nil
+	}
+	return base64.StdEncoding.DecodeString(s[len(binaryPrefix):])
+}
+
+// Decode decodes all config values from r in given file format,
+// FormatDir can not be decoded from stream
+func Decode(r io.Reader, format Format) (map[string][]byte, error) {
+	var (
+		m   map[string]string
+		err error
+	)
+	switch format {
+	case FormatYAML:
+		m, err = reader.DecodeYAML(r)
+	case FormatJSON:
+		m, err = reader.DecodeJSON(r)
+	case FormatDotEnv:
+		m, err = godotenv.Parse(r)
+	default:
+		return nil, fmt.Errorf("configfile: can not decode %s format from stream", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string][]byte, len(m))
+	for k, v := range m {
+		if format == FormatDotEnv {
+			k = strings.ToLower(k)
+		}
+		if format == FormatYAML {
+			// yaml keeps binary data as !!binary
+			data[k] = []byte(v)
+			continue
+		}
+		data[k], err = decodeValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("configfile: invalid binary value of %s; %w", k, err)
+		}
+	}
+	return data, nil
+}
+
+// Encode encodes config values into w in given file format,
+// binary values are written as !!binary in yaml,
+// and as base64 with "base64:" prefix in json and .env
+func Encode(w io.Writer, format Format, data map[string][]byte) error {
+	m := make(map[string]string, len(data))
+	for k, v := range data {
+		if format == FormatYAML {
+			m[k] = string(v)
+			continue
+		}
+		m[k] = encodeValue(v)
+	}
+
+	switch format {
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		err := enc.Encode(m)
+		if err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(m)
+	case FormatDotEnv:
+		return encodeDotEnv(w, m)
+	}
+	return fmt.Errorf("configfile: can not encode %s format to stream", format)
+}
+
+// envKeyReplacer maps nested key separators to env name separator
+var envKeyReplacer = strings.NewReplacer("/", "_", ".", "_", "-", "_")
+
+// dotEnvKey returns env name of k, e.g. redis/addr is REDIS_ADDR
+func dotEnvKey(k string) string {
+	return strings.ToUpper(envKeyReplacer.Replace(k))
+}
+
+// encodeDotEnv writes .env file, keys are upper cased with "/", "." and "-" replaced by "_"
+// to match NewEnvReader, values are always quoted, a value that godotenv can not read back
+// unchanged (e.g. ending with backslash) is written as base64
+func encodeDotEnv(w io.Writer, m map[string]string) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	seen := make(map[string]string, len(keys))
+	for _, k := range keys {
+		key := dotEnvKey(k)
+		if old, ok := seen[key]; ok {
+			return fmt.Errorf("configfile: keys %s and %s are both %s in .env", old, k, key)
+		}
+		seen[key] = k
+	}
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		key := dotEnvKey(k)
+		line := key + "=\"" + dotEnvEscape(m[k]) + "\"\n"
+		if p, err := godotenv.Unmarshal(line); err != nil || p[key] != m[k] {
+			line = key + "=\"" + binaryPrefix + base64.StdEncoding.EncodeToString([]byte(m[k])) + "\"\n"
+		}
+		buf.WriteString(line)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func dotEnvEscape(s string) string {
+	for _, c := range "\\\n\r\"!$`" {
+		r := "\\" + string(c)
+		switch c {
+		case '\n':
+			r = `\n`
+		case '\r':
+			r = `\r`
+		}
+		s = strings.ReplaceAll(s, string(c), r)
+	}
+	return s
+}
+
+// ReadAll reads all config values from path in given format,
+// for FormatDir kubernetes internal entries (prefixed with "..") are skipped
+func ReadAll(path string, format Format) (map[string][]byte, error) {
+	if format == FormatDir {
+		return reader.ReadDir(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Decode(f, format)
+}
+
+// WriteAll writes all config values to path in given format,
+// for FormatDir each value is written into its own file
+func WriteAll(path string, format Format, data map[string][]byte) error {
+	if format == FormatDir {
+		return writeDir(path, data)
+	}
+
+	var buf bytes.Buffer
+	err := Encode(&buf, format, data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func writeDir(base string, data map[string][]byte) error {
+	for k, v := range data {
+		if !fs.ValidPath(k) {
+			return fmt.Errorf("configfile: invalid key %q for dir format", k)
+		}
+		fn := filepath.Join(base, filepath.FromSlash(k))
+		err := os.MkdirAll(filepath.Dir(fn), 0755)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(fn, v, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Convert reads all config values from src then writes to dst
+func Convert(dst string, dstFormat Format, src string, srcFormat Format) error {
+	data, err := ReadAll(src, srcFormat)
+	if err != nil {
+		return err
+	}
+	return WriteAll(dst, dstFormat, data)
+}
@@ -0,0 +1,128 @@
+package configfile_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestConvert(t *testing.T) {
+	data := map[string][]byte{
+		"data1":  []byte("true"),
+		"data3":  []byte("007"),
+		"text":   []byte("line1\nline2 \"$HOME\" `x`\\"),
+		"empty":  []byte(""),
+		"binary": {0xff, 0x00, 'a'},
+		"prefix": []byte("base64:not really"),
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	require.NoError(t, configfile.WriteAll(src, configfile.FormatDir, data))
+
+	for _, format := range []configfile.Format{
+		configfile.FormatYAML,
+		configfile.FormatJSON,
+		configfile.FormatDotEnv,
+	} {
+		t.Run(string(format), func(t *testing.T) {
+			fn := filepath.Join(dir, "config."+string(format))
+			require.NoError(t, configfile.Convert(fn, format, src, configfile.FormatDir))
+
+			out := filepath.Join(dir, "out-"+string(format))
+			require.NoError(t, configfile.Convert(out, configfile.FormatDir, fn, format))
+
+			got, err := configfile.ReadAll(out, configfile.FormatDir)
+			require.NoError(t, err)
+			assert.Equal(t, data, got)
+		})
+	}
+}
+
+func TestConvertNestedDotEnv(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	require.NoError(t, configfile.WriteAll(src, configfile.FormatDir, map[string][]byte{
+		"redis/addr":     []byte("localhost:6379"),
+		"db.host":        []byte("db"),
+		"feature-x/rate": []byte("10"),
+	}))
+
+	fn := filepath.Join(dir, "config.env")
+	require.NoError(t, configfile.Convert(fn, configfile.FormatDotEnv, src, configfile.FormatDir))
+
+	m, err := godotenv.Read(fn)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"REDIS_ADDR":     "localhost:6379",
+		"DB_HOST":        "db",
+		"FEATURE_X_RATE": "10",
+	}, m)
+
+	out := filepath.Join(dir, "out")
+	require.NoError(t, configfile.Convert(out, configfile.FormatDir, fn, configfile.FormatDotEnv))
+	got, err := configfile.ReadAll(out, configfile.FormatDir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"redis_addr":     []byte("localhost:6379"),
+		"db_host":        []byte("db"),
+		"feature_x_rate": []byte("10"),
+	}, got)
+
+	var buf bytes.Buffer
+	assert.EqualError(t, configfile.Encode(&buf, configfile.FormatDotEnv, map[string][]byte{
+		"redis/addr": []byte("a"),
+		"redis_addr": []byte("b"),
+	}), "configfile: keys redis/addr and redis_addr are both REDIS_ADDR in .env")
+}
+
+func TestConvertFromTestdata(t *testing.T) {
+	want, err := configfile.ReadAll("testdata/config.yaml", configfile.FormatYAML)
+	require.NoError(t, err)
+
+	for _, fn := range []string{"testdata/config.json", "testdata/config.env"} {
+		format, err := configfile.DetectFormat(fn)
+		require.NoError(t, err)
+
+		got, err := configfile.ReadAll(fn, format)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, fn)
+	}
+}
+
+func TestEncodeDotEnvReadable(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, configfile.Encode(&buf, configfile.FormatDotEnv, map[string][]byte{
+		"redis_addr": []byte("localhost:6379"),
+	}))
+
+	c := configfile.NewDotEnvReaderFromReader(&buf)
+	assert.Equal(t, "localhost:6379", c.String("redis_addr"))
+}
+
+func TestDetectFormat(t *testing.T) {
+	cases := map[string]configfile.Format{
+		"testdata":        configfile.FormatDir,
+		"config":          configfile.FormatDir,
+		"config.yaml":     configfile.FormatYAML,
+		"config.yml":      configfile.FormatYAML,
+		"config.json":     configfile.FormatJSON,
+		"prod.env":        configfile.FormatDotEnv,
+		".env":            configfile.FormatDotEnv,
+		".env.production": configfile.FormatDotEnv,
+	}
+	for fn, format := range cases {
+		f, err := configfile.DetectFormat(fn)
+		assert.NoError(t, err, fn)
+		assert.Equal(t, format, f, fn)
+	}
+
+	_, err := configfile.DetectFormat("config.toml")
+	assert.Error(t, err)
+}
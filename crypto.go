@@ -0,0 +1,106 @@
+package configfile
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// encryptedPrefix marks encrypted values, the rest of value is passed to Decrypter
+const encryptedPrefix = "enc:"
+
+// Decrypter decrypts encrypted config values,
+// values in form "enc:<ciphertext>" are decrypted with <ciphertext>
+type Decrypter interface {
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+func decrypt(d Decrypter, b []byte) ([]byte, error) {
+	if !bytes.HasPrefix(b, []byte(encryptedPrefix)) {
+		return b, nil
+	}
+	return d.Decrypt(b[len(encryptedPrefix):])
+}
+
+// Decrypter sets decrypter for values from this reader and its fallback
+func (r *Reader) Decrypter(d Decrypter) *Reader {
+	r.decrypter = d
+	return r
+}
+
+const aesGCMVersion = "v1:"
+
+// AESGCM encrypts and decrypts config values using AES-GCM,
+// encrypted value is in form "enc:v1:<base64 of nonce and ciphertext>"
+type AESGCM struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCM creates new AES-GCM decrypter from 16, 24 or 32 bytes key
+func NewAESGCM(key []byte) (*AESGCM, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &AESGCM{aead}, nil
+}
+
+// NewAESGCMFromFile creates new AES-GCM decrypter from key file,
+// the file contains base64 encoded key, surrounding spaces are ignored
+func NewAESGCMFromFile(filename string) (*AESGCM, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(b)))
+	if err != nil {
+		return nil, fmt.Errorf("configfile: invalid key file; %w", err)
+	}
+	return NewAESGCM(key)
+}
+
+// GenerateAESKey generates random 32 bytes key encoded in base64, suitable for key file
+func GenerateAESKey() (string, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Encrypt encrypts plaintext into value that can be read by Reader with this decrypter
+func (c *AESGCM) Encrypt(plaintext []byte) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	b := c.aead.Seal(nonce, nonce, plaintext, nil)
+	return encryptedPrefix + aesGCMVersion + base64.StdEncoding.EncodeToString(b), nil
+}
+
+// Decrypt decrypts ciphertext in form "v1:<base64 of nonce and ciphertext>"
+func (c *AESGCM) Decrypt(ciphertext []byte) ([]byte, error) {
+	if !bytes.HasPrefix(ciphertext, []byte(aesGCMVersion)) {
+		return nil, errors.New("configfile: unsupported encrypted value version")
+	}
+	b, err := base64.StdEncoding.DecodeString(string(ciphertext[len(aesGCMVersion):]))
+	if err != nil {
+		return nil, err
+	}
+	n := c.aead.NonceSize()
+	if len(b) < n {
+		return nil, errors.New("configfile: encrypted value too short")
+	}
+	return c.aead.Open(nil, b[:n], b[n:], nil)
+}
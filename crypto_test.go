@@ -0,0 +1,52 @@
+package configfile_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestAESGCM(t *testing.T) {
+	key, err := configfile.GenerateAESKey()
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(key+"\n"), 0600))
+
+	d, err := configfile.NewAESGCMFromFile(keyFile)
+	require.NoError(t, err)
+
+	password, err := d.Encrypt([]byte("secret"))
+	require.NoError(t, err)
+	port, err := d.Encrypt([]byte("6379"))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, configfile.Encode(&buf, configfile.FormatYAML, map[string][]byte{
+		"password": []byte(password),
+		"port":     []byte(port),
+		"host":     []byte("localhost"),
+		"broken":   []byte("enc:v1:AAAA"),
+	}))
+
+	c := configfile.NewYAMLReaderFromReader(&buf).Decrypter(d)
+	assert.Equal(t, "secret", c.String("password"))
+	assert.Equal(t, 6379, c.Int("port"))
+	assert.Equal(t, "localhost", c.String("host"))
+	assert.Equal(t, "default", c.StringDefault("broken", "default"))
+	assert.Panics(t, func() { c.MustString("broken") })
+
+	t.Run("WrongKey", func(t *testing.T) {
+		other, err := configfile.NewAESGCM(bytes.Repeat([]byte{1}, 32))
+		require.NoError(t, err)
+
+		c := configfile.NewArgsReader([]string{"--password=" + password}).Decrypter(other)
+		assert.Equal(t, "", c.String("password"))
+	})
+}
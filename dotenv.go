@@ -0,0 +1,76 @@
+package configfile
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/joho/godotenv"
+)
+
+// DotEnvFlowOptions is the options for LoadDotEnvFlow
+type DotEnvFlowOptions struct {
+	// Dir is the directory contains .env files, default to current directory
+	Dir string
+
+	// Env is the environment name, default to APP_ENV env
+	Env string
+
+	// Overload allows .env files to override existing env
+	Overload bool
+}
+
+// LoadDotEnvFlow loads .env, .env.local, .env.<env> and .env.<env>.local
+// in dotenv-flow order, a later file overrides an earlier one,
+// .env.local is skipped when env is "test".
+// Existing env wins over all files unless Overload is set.
+//
+// It returns the files that were applied, in load order
+func LoadDotEnvFlow(opt DotEnvFlowOptions) ([]string, error) {
+	env := opt.Env
+	if env == "" {
+		env = os.Getenv(ProfileEnv)
+	}
+
+	names := []string{".env"}
+	if env != "test" {
+		names = append(names, ".env.local")
+	}
+	if env != "" {
+		names = append(names, ".env."+env, ".env."+env+".local")
+	}
+
+	var files []string
+	m := make(map[string]string)
+	for _, name := range names {
+		fn := filepath.Join(opt.Dir, name)
+		if _, err := os.Stat(fn); err != nil {
+			continue
+		}
+		p, err := godotenv.Read(fn)
+		if err != nil {
+			return files, err
+		}
+		for k, v := range p {
+			m[k] = v
+		}
+		files = append(files, fn)
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if _, ok := os.LookupEnv(k); ok && !opt.Overload {
+			continue
+		}
+		err := os.Setenv(k, m[k])
+		if err != nil {
+			return files, err
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,76 @@
+package configfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+func writeDotEnvFlow(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		".env":                  "FLOW_A=env\nFLOW_B=env\nFLOW_C=env\nFLOW_D=env\nFLOW_E=env\n",
+		".env.local":            "FLOW_B=local\nFLOW_C=local\nFLOW_D=local\n",
+		".env.production":       "FLOW_C=production\nFLOW_D=production\n",
+		".env.production.local": "FLOW_D=production.local\n",
+		".env.test":             "FLOW_C=test\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	for _, k := range []string{"FLOW_A", "FLOW_B", "FLOW_C", "FLOW_D"} {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+	t.Setenv("FLOW_E", "existing")
+	return dir
+}
+
+func TestLoadDotEnvFlow(t *testing.T) {
+	t.Run("Production", func(t *testing.T) {
+		dir := writeDotEnvFlow(t)
+
+		files, err := configfile.LoadDotEnvFlow(configfile.DotEnvFlowOptions{Dir: dir, Env: "production"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(dir, ".env"),
+			filepath.Join(dir, ".env.local"),
+			filepath.Join(dir, ".env.production"),
+			filepath.Join(dir, ".env.production.local"),
+		}, files)
+
+		c := configfile.NewEnvReader()
+		assert.Equal(t, "env", c.String("flow_a"))
+		assert.Equal(t, "local", c.String("flow_b"))
+		assert.Equal(t, "production", c.String("flow_c"))
+		assert.Equal(t, "production.local", c.String("flow_d"))
+		assert.Equal(t, "existing", c.String("flow_e"))
+	})
+
+	t.Run("Test", func(t *testing.T) {
+		dir := writeDotEnvFlow(t)
+
+		files, err := configfile.LoadDotEnvFlow(configfile.DotEnvFlowOptions{Dir: dir, Env: "test"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dir, ".env"), filepath.Join(dir, ".env.test")}, files)
+		assert.Equal(t, "env", os.Getenv("FLOW_B"), ".env.local must be skipped in test")
+		assert.Equal(t, "test", os.Getenv("FLOW_C"))
+	})
+
+	t.Run("Overload", func(t *testing.T) {
+		dir := writeDotEnvFlow(t)
+		t.Setenv("APP_ENV", "")
+
+		_, err := configfile.LoadDotEnvFlow(configfile.DotEnvFlowOptions{Dir: dir, Overload: true})
+		require.NoError(t, err)
+		assert.Equal(t, "env", os.Getenv("FLOW_E"))
+		assert.Equal(t, "local", os.Getenv("FLOW_D"))
+	})
+}
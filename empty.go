@@ -0,0 +1,58 @@
+package configfile
+
+import "errors"
+
+// ErrEmpty is returned when value is empty and reader uses EmptyAsError
+var ErrEmpty = errors.New("configfile: empty value")
+
+// errUnset marks empty value that treated as not found
+var errUnset = errors.New("configfile: empty value is unset")
+
+// EmptyPolicy is how empty values are handled by accessors
+type EmptyPolicy int
+
+// Empty policies
+const (
+	// EmptyAsValue passes empty value to accessors as is,
+	// String and Bytes return empty, Bool returns default,
+	// other types fail to parse then return default or panic
+	EmptyAsValue EmptyPolicy = iota
+
+	// EmptyAsUnset treats empty value as not found,
+	// the name is looked up in fallback readers then default is used
+	EmptyAsUnset
+
+	// EmptyAsZero returns zero value of the type for empty value
+	EmptyAsZero
+
+	// EmptyAsError treats empty value as invalid for all types,
+	// accessors return default and Must accessors panic with ErrEmpty
+	EmptyAsError
+)
+
+// Empty sets how empty values are handled for reads from this reader and its fallback,
+// default is EmptyAsValue
+func (r *Reader) Empty(p EmptyPolicy) *Reader {
+	r.empty = p
+	return r
+}
+
+// zero reports whether s is empty and should be read as zero value
+func (r *Reader) zero(s string) bool {
+	return s == "" && r.empty == EmptyAsZero
+}
+
+// IsSet reports whether name is found in any source of the chain,
+// empty value is set regardless of empty policy
+func (r *Reader) IsSet(name string) bool {
+	r.record(name)
+	for p := r; p != nil; p = p.fallback {
+		if _, ok := p.overridden(name); ok {
+			return true
+		}
+		if _, err := p.r.Read(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package configfile_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acoshift/configfile"
+)
+
+func emptyReader() *configfile.Reader {
+	return configfile.NewMapReader(map[string]string{"port": "", "debug": "", "name": "", "timeout": ""}).
+		Fallback(configfile.NewMapReader(map[string]string{"port": "8080", "debug": "true"}))
+}
+
+func TestEmptyAsValue(t *testing.T) {
+	c := emptyReader()
+	assert.Equal(t, 1, c.IntDefault("port", 1))
+	assert.True(t, c.BoolDefault("debug", true))
+	assert.Equal(t, "", c.StringDefault("name", "a"))
+	assert.Panics(t, func() { c.MustInt("port") })
+	assert.NotPanics(t, func() { c.MustString("name") })
+}
+
+func TestEmptyAsUnset(t *testing.T) {
+	c := emptyReader().Empty(configfile.EmptyAsUnset)
+	assert.Equal(t, 8080, c.IntDefault("port", 1))
+	assert.True(t, c.Bool("debug"))
+	assert.Equal(t, "a", c.StringDefault("name", "a"))
+	assert.Equal(t, []byte("b"), c.BytesDefault("name", []byte("b")))
+	assert.Equal(t, 8080, configfile.Get[int](c, "port"))
+	assert.Panics(t, func() { c.MustString("name") })
+	assert.True(t, c.IsSet("name"))
+
+	restore := c.Override("port", "")
+	assert.Equal(t, 8080, c.Int("port"))
+	restore()
+}
+
+func TestEmptyAsZero(t *testing.T) {
+	c := emptyReader().Empty(configfile.EmptyAsZero)
+	assert.Equal(t, 0, c.IntDefault("port", 1))
+	assert.Equal(t, int64(0), c.Int64Default("port", 1))
+	assert.Equal(t, float32(0), c.Float32Default("port", 1))
+	assert.Equal(t, float64(0), c.Float64Default("port", 1))
+	assert.False(t, c.BoolDefault("debug", true))
+	assert.Equal(t, time.Duration(0), c.DurationDefault("timeout", time.Second))
+	assert.Equal(t, "", c.StringDefault("name", "a"))
+	assert.Equal(t, 0, configfile.GetDefault(c, "port", 1))
+	assert.Equal(t, 0, configfile.Watch(c, "port", 1).Load())
+	assert.NotPanics(t, func() { c.MustInt("port") })
+	assert.NotPanics(t, func() { c.MustBool("debug") })
+}
+
+func TestEmptyAsError(t *testing.T) {
+	c := emptyReader().Empty(configfile.EmptyAsError)
+	assert.Equal(t, 1, c.IntDefault("port", 1))
+	assert.True(t, c.BoolDefault("debug", true))
+	assert.Equal(t, "a", c.StringDefault("name", "a"))
+	assert.Equal(t, "a", configfile.GetDefault(c, "name", "a"))
+	assert.PanicsWithValue(t, configfile.ErrEmpty, func() { c.MustString("name") })
+	assert.PanicsWithValue(t, configfile.ErrEmpty, func() { c.MustBytes("name") })
+}
+
+func TestIsSet(t *testing.T) {
+	c := emptyReader()
+	assert.True(t, c.IsSet("port"))
+	assert.True(t, c.IsSet("name"))
+	assert.False(t, c.IsSet("notfound"))
+
+	restore := c.Override("notfound", "")
+	assert.True(t, c.IsSet("notfound"))
+	restore()
+	assert.False(t, c.IsSet("notfound"))
+
+	assert.True(t, configfile.NewEnvReader().IsSet("empty"))
+}
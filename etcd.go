@@ -0,0 +1,22 @@
+package configfile
+
+import (
+	"os"
+	"strings"
+
+	"github.com/acoshift/configfile/internal/reader"
+)
+
+// EtcdOptions configures NewEtcdReader
+type EtcdOptions = reader.EtcdOptions
+
+// NewEtcdReader creates new reader from etcd v3 keys under opt.Prefix,
+// keys are loaded at once through etcd grpc gateway.
+// Empty Endpoint is taken from the first of ETCD_ENDPOINTS env.
+// Call Reload to load keys again, or WatchRemote to receive changes from etcd watch
+func NewEtcdReader(opt EtcdOptions) *Reader {
+	if opt.Endpoint == "" {
+		opt.Endpoint, _, _ = strings.Cut(os.Getenv("ETCD_ENDPOINTS"), ",")
+	}
+	return &Reader{r: reader.NewEtcd(opt)}
+}
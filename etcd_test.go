@@ -0,0 +1,249 @@
+package configfile_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/server/v3/embed"
+
+	"github.com/acoshift/configfile"
+)
+
+// etcdServer is an embedded single node etcd, changed through its grpc gateway
+type etcdServer struct {
+	t     *testing.T
+	URL   string
+	token string
+
+	// Client must be used by readers, so Close can drop its idle connections,
+	// etcd waits for the first request of accepted connections before shutdown
+	Client *http.Client
+
+	// Close stops the server
+	Close func()
+}
+
+func newEtcd(t *testing.T) *etcdServer {
+	t.Helper()
+
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	cfg.BcryptCost = 4 // bcrypt.MinCost
+	// grpc gateway dials the client url, so it needs a real port
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	client := url.URL{Scheme: "http", Host: l.Addr().String()}
+	l.Close()
+	peer := url.URL{Scheme: "http", Host: "127.0.0.1:0"}
+	cfg.ListenClientUrls = []url.URL{client}
+	cfg.AdvertiseClientUrls = []url.URL{client}
+	cfg.ListenPeerUrls = []url.URL{peer}
+	cfg.AdvertisePeerUrls = []url.URL{peer}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+
+	e, err := embed.StartEtcd(cfg)
+	require.NoError(t, err)
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	s := &etcdServer{
+		t:      t,
+		URL:    "http://" + e.Clients[0].Addr().String(),
+		Client: &http.Client{Transport: tr},
+	}
+	var once sync.Once
+	s.Close = func() {
+		once.Do(func() {
+			tr.CloseIdleConnections()
+			e.Close()
+		})
+	}
+	t.Cleanup(s.Close)
+
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatal("etcd not ready")
+	}
+	return s
+}
+
+func (s *etcdServer) call(p string, body interface{}) []byte {
+	s.t.Helper()
+
+	b, err := json.Marshal(body)
+	require.NoError(s.t, err)
+	req, err := http.NewRequest(http.MethodPost, s.URL+p, bytes.NewReader(b))
+	require.NoError(s.t, err)
+	if s.token != "" {
+		req.Header.Set("Authorization", s.token)
+	}
+	resp, err := s.Client.Do(req)
+	require.NoError(s.t, err)
+	defer resp.Body.Close()
+	b, err = io.ReadAll(resp.Body)
+	require.NoError(s.t, err)
+	require.Equal(s.t, http.StatusOK, resp.StatusCode, string(b))
+	return b
+}
+
+// put puts key and returns revision of the change
+func (s *etcdServer) put(key, value string) int64 {
+	s.t.Helper()
+
+	var res struct {
+		Header struct {
+			Revision int64 `json:"revision,string"`
+		} `json:"header"`
+	}
+	require.NoError(s.t, json.Unmarshal(s.call("/v3/kv/put", map[string]interface{}{"key": []byte(key), "value": []byte(value)}), &res))
+	return res.Header.Revision
+}
+
+func (s *etcdServer) del(key string) {
+	s.t.Helper()
+	s.call("/v3/kv/deleterange", map[string]interface{}{"key": []byte(key)})
+}
+
+// enableAuth adds user root with password pass and enables auth
+func (s *etcdServer) enableAuth() {
+	s.t.Helper()
+
+	s.call("/v3/auth/user/add", map[string]string{"name": "root", "password": "pass"})
+	s.call("/v3/auth/role/add", map[string]string{"name": "root"})
+	s.call("/v3/auth/user/grant", map[string]string{"user": "root", "role": "root"})
+	s.call("/v3/auth/enable", map[string]string{})
+
+	var res struct {
+		Token string `json:"token"`
+	}
+	require.NoError(s.t, json.Unmarshal(s.call("/v3/auth/authenticate", map[string]string{"name": "root", "password": "pass"}), &res))
+	s.token = res.Token
+}
+
+func TestEtcdReader(t *testing.T) {
+	s := newEtcd(t)
+	s.put("myapp/port", "8080")
+	s.put("myapp/redis/addr", "localhost:6379")
+	s.put("other/port", "9000")
+
+	c := configfile.NewEtcdReader(configfile.EtcdOptions{Endpoint: s.URL, Client: s.Client, Prefix: "myapp"})
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.Equal(t, "localhost:6379", c.Sub("redis").String("addr"))
+	assert.Equal(t, []string{"port", "redis/addr"}, c.Keys())
+
+	s.put("myapp/port", "8081")
+	assert.Equal(t, 8080, c.Int("port"))
+	require.NoError(t, c.Reload())
+	assert.Equal(t, 8081, c.Int("port"))
+}
+
+func TestEtcdReaderWatch(t *testing.T) {
+	s := newEtcd(t)
+	s.put("myapp/port", "8080")
+	s.put("myapp/debug", "true")
+	s.enableAuth()
+
+	c := configfile.NewEtcdReader(configfile.EtcdOptions{
+		Endpoint: s.URL,
+		Client:   s.Client,
+		Prefix:   "myapp/",
+		Username: "root",
+		Password: "pass",
+	}).Debounce(0)
+	assert.Equal(t, 8080, c.Int("port"))
+	port := configfile.Watch(c, "port", 0)
+	debug := configfile.Watch(c, "debug", false)
+
+	stop := c.WatchRemote()
+	defer stop()
+
+	// watch starts from the loaded revision, changes before the stream opens are not missed
+	s.put("other/port", "9000")
+	s.put("myapp/port", "8081")
+	s.del("myapp/debug")
+	assert.Eventually(t, func() bool { return port.Load() == 8081 && !debug.Load() }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"port"}, c.Keys())
+}
+
+func TestEtcdReaderWatchCompacted(t *testing.T) {
+	s := newEtcd(t)
+	s.put("myapp/port", "8080")
+
+	c := configfile.NewEtcdReader(configfile.EtcdOptions{Endpoint: s.URL, Client: s.Client, Prefix: "myapp"}).Debounce(0)
+	port := configfile.Watch(c, "port", 0)
+
+	s.put("myapp/port", "8081")
+	rev := s.put("other/port", "9000")
+	s.call("/v3/kv/compaction", map[string]interface{}{"revision": strconv.FormatInt(rev, 10), "physical": true})
+
+	stop := c.WatchRemote()
+	defer stop()
+
+	assert.Eventually(t, func() bool { return port.Load() == 8081 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestEtcdReaderUnavailable(t *testing.T) {
+	c := configfile.NewEtcdReader(configfile.EtcdOptions{Endpoint: "127.0.0.1:1", Prefix: "myapp"}).
+		Fallback(configfile.NewMapReader(map[string]string{"port": "8080"}))
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.Error(t, c.Reload())
+
+	s := newEtcd(t)
+	s.enableAuth()
+	c = configfile.NewEtcdReader(configfile.EtcdOptions{Endpoint: s.URL, Client: s.Client, Username: "root", Password: "wrong"})
+	assert.Error(t, c.Reload())
+	c = configfile.NewEtcdReader(configfile.EtcdOptions{Endpoint: s.URL, Client: s.Client})
+	assert.Error(t, c.Reload(), "must fail without credentials")
+}
+
+func TestEtcdReaderWatchError(t *testing.T) {
+	s := newEtcd(t)
+	s.put("myapp/port", "8080")
+
+	c := configfile.NewEtcdReader(configfile.EtcdOptions{Endpoint: s.URL, Client: s.Client, Prefix: "myapp"})
+	errs := make(chan error, 10)
+	c.OnWatchError(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+
+	stop := c.WatchRemote()
+	defer stop()
+	s.Close()
+
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("error not reported")
+	}
+	assert.Equal(t, 8080, c.Int("port"), "must serve last values")
+}
+
+func TestEtcdReaderTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	start := time.Now()
+	c := configfile.NewEtcdReader(configfile.EtcdOptions{Endpoint: srv.URL, Timeout: 50 * time.Millisecond}).
+		Fallback(configfile.NewMapReader(map[string]string{"port": "8080"}))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 8080, c.Int("port"))
+}
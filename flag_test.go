@@ -0,0 +1,25 @@
+package configfile_test
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestFlagReader(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("workers", 4, "")
+	configfile.RegisterFlags(fs, "data3", "redis_addr", "workers", "data5")
+	require.NoError(t, fs.Parse([]string{"--data3=10", "--redis-addr", "localhost:6379"}))
+
+	c := configfile.NewFlagReader(fs).Fallback(configfile.NewReader("testdata"))
+	assert.Equal(t, 10, c.Int("data3"))
+	assert.Equal(t, "localhost:6379", c.String("redis_addr"))
+	assert.Equal(t, "3m5s", c.String("data5"), "not set flag must fallback")
+	assert.Equal(t, 1, c.IntDefault("workers", 1), "default value of flag must not be used")
+	assert.True(t, c.Bool("data1"))
+}
@@ -0,0 +1,44 @@
+package configfile_test
+
+import (
+	"embed"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+//go:embed testdata
+var testdata embed.FS
+
+func TestFSReader(t *testing.T) {
+	fsys, err := fs.Sub(testdata, "testdata")
+	require.NoError(t, err)
+
+	c := configfile.NewFSReader(fsys)
+	testReader(t, c)
+	assert.Contains(t, c.Keys(), "sub/redis/addr")
+	assert.Equal(t, "localhost:6379", c.Sub("sub").Sub("redis").String("addr"))
+}
+
+func TestFSReaderMapFS(t *testing.T) {
+	c := configfile.NewFSReader(fstest.MapFS{
+		"addr":              {Data: []byte(":8080")},
+		"redis/addr":        {Data: []byte("localhost:6379")},
+		"..data/addr":       {Data: []byte(":8080")},
+		"..2024_01_01/addr": {Data: []byte(":8080")},
+	}).Fallback(configfile.NewEnvReader())
+
+	assert.Equal(t, ":8080", c.String("addr"))
+	assert.Equal(t, "localhost:6379", c.Sub("redis").String("addr"))
+	assert.Equal(t, 1, c.Int("onlyenv"))
+	assert.Equal(t, []string{"addr", "redis/addr"}, configfile.NewFSReader(fstest.MapFS{
+		"addr":        {Data: []byte(":8080")},
+		"redis/addr":  {Data: []byte("localhost:6379")},
+		"..data/addr": {Data: []byte(":8080")},
+	}).Keys())
+}
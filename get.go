@@ -0,0 +1,46 @@
+package configfile
+
+// GetDefault reads config then parses as T with default value,
+// supports string, []byte, bool, int, int64, float32, float64, time.Duration,
+// types implementing encoding.TextUnmarshaler and pointers to them (*big.Int),
+// and types registered with RegisterParser
+func GetDefault[T any](r *Reader, name string, def T) T {
+	b, err := r.read(name)
+	if err != nil {
+		return def
+	}
+	v, err := parseValue[T](r, b)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// Get reads config then parses as T, see GetDefault
+func Get[T any](r *Reader, name string) T {
+	var def T
+	return GetDefault(r, name, def)
+}
+
+// MustGet reads config then parses as T, see GetDefault,
+// panic if config not found or data can not parse to T
+func MustGet[T any](r *Reader, name string) T {
+	b, err := r.read(name)
+	if err != nil {
+		panic(err)
+	}
+	v, err := parseValue[T](r, b)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// parseValue parses b as T following empty policy of r
+func parseValue[T any](r *Reader, b []byte) (T, error) {
+	if r.zero(string(b)) {
+		var v T
+		return v, nil
+	}
+	return parse[T](b)
+}
@@ -0,0 +1,59 @@
+package configfile_test
+
+import (
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acoshift/configfile"
+)
+
+type level int
+
+func TestGet(t *testing.T) {
+	c := configfile.NewReader("testdata")
+
+	assert.True(t, configfile.Get[bool](c, "data1"))
+	assert.Equal(t, 9, configfile.Get[int](c, "data3"))
+	assert.Equal(t, 3*time.Minute+5*time.Second, configfile.Get[time.Duration](c, "data5"))
+	assert.Equal(t, 0, configfile.Get[int](c, "notfound"))
+	assert.Equal(t, 1, configfile.GetDefault(c, "data1", 1))
+	assert.Panics(t, func() { configfile.MustGet[int](c, "notfound") })
+	assert.Panics(t, func() { configfile.MustGet[int](c, "data1") })
+	assert.NotPanics(t, func() { configfile.MustGet[float64](c, "data7") })
+
+	t.Run("TextUnmarshaler", func(t *testing.T) {
+		c := configfile.NewArgsReader([]string{"--ip=10.0.0.1", "--bad-ip=x"})
+		assert.Equal(t, net.ParseIP("10.0.0.1"), configfile.Get[net.IP](c, "ip"))
+		assert.Nil(t, configfile.Get[net.IP](c, "bad_ip"))
+		assert.Panics(t, func() { configfile.MustGet[net.IP](c, "bad_ip") })
+	})
+
+	t.Run("PointerTextUnmarshaler", func(t *testing.T) {
+		c := configfile.NewArgsReader([]string{"--supply=123456789012345678901234567890", "--bad=x"})
+		n, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+		assert.Equal(t, n, configfile.MustGet[*big.Int](c, "supply"))
+		assert.Nil(t, configfile.Get[*big.Int](c, "bad"))
+		assert.Nil(t, configfile.Get[*big.Int](c, "notfound"))
+		assert.Panics(t, func() { configfile.MustGet[*big.Int](c, "bad") })
+		assert.Panics(t, func() { configfile.MustGet[*int](c, "supply") })
+	})
+
+	t.Run("RegisterParser", func(t *testing.T) {
+		configfile.RegisterParser(func(s string) (level, error) {
+			return level(strings.Count(s, "!")), nil
+		})
+
+		c := configfile.NewArgsReader([]string{"--level=!!!"})
+		assert.Equal(t, level(3), configfile.Get[level](c, "level"))
+		assert.Equal(t, level(3), configfile.Watch(c, "level", level(0)).Load())
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		assert.Panics(t, func() { configfile.MustGet[struct{}](c, "data1") })
+	})
+}
@@ -0,0 +1,19 @@
+package configfile
+
+import "github.com/acoshift/configfile/internal/reader"
+
+// HTTPOptions configures NewHTTPReaderWithOptions
+type HTTPOptions = reader.HTTPOptions
+
+// NewHTTPReader creates new reader from json or yaml document served at url,
+// nested keys are flatten like NewJSONReader.
+// The document is fetched at once, call Reload or ReloadEvery to poll,
+// unchanged document is not downloaded again when server sends ETag
+func NewHTTPReader(url string) *Reader {
+	return NewHTTPReaderWithOptions(url, HTTPOptions{})
+}
+
+// NewHTTPReaderWithOptions creates new http reader with options, see NewHTTPReader
+func NewHTTPReaderWithOptions(url string, opt HTTPOptions) *Reader {
+	return &Reader{r: reader.NewHTTP(url, opt)}
+}
@@ -0,0 +1,186 @@
+package configfile_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+// fakeConfigServer serves documents with etag, fails the first fails requests
+type fakeConfigServer struct {
+	mu       sync.Mutex
+	docs     map[string]string
+	fails    int32
+	requests int32
+	notMod   int32
+}
+
+func (s *fakeConfigServer) set(p, body string) {
+	s.mu.Lock()
+	s.docs[p] = body
+	s.mu.Unlock()
+}
+
+func (s *fakeConfigServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&s.requests, 1)
+	if atomic.AddInt32(&s.fails, -1) >= 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	s.mu.Lock()
+	body, ok := s.docs[r.URL.Path]
+	s.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	etag := `"` + body + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		atomic.AddInt32(&s.notMod, 1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	if strings.HasSuffix(r.URL.Path, ".yml") {
+		w.Header().Set("Content-Type", "application/yaml")
+	}
+	w.Write([]byte(body))
+}
+
+func newFakeConfigServer(t *testing.T) (*fakeConfigServer, *httptest.Server) {
+	s := &fakeConfigServer{docs: make(map[string]string)}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+var authHeader = http.Header{"Authorization": {"Bearer token"}}
+
+func TestHTTPReader(t *testing.T) {
+	s, srv := newFakeConfigServer(t)
+	s.set("/myapp.json", `{"port": 8080, "redis": {"addr": "localhost:6379"}}`)
+
+	c := configfile.NewHTTPReaderWithOptions(srv.URL+"/myapp.json", configfile.HTTPOptions{Header: authHeader})
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.Equal(t, "localhost:6379", c.Sub("redis").String("addr"))
+	assert.Equal(t, []string{"port", "redis.addr"}, c.Keys())
+
+	require.NoError(t, c.Reload())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&s.notMod))
+
+	s.set("/myapp.json", `{"port": 8081}`)
+	require.NoError(t, c.Reload())
+	assert.Equal(t, 8081, c.Int("port"))
+	assert.Equal(t, "", c.String("redis.addr"))
+
+	s.set("/myapp.json", `{invalid`)
+	assert.Error(t, c.Reload())
+	assert.Equal(t, 8081, c.Int("port"))
+}
+
+func TestHTTPReaderYAML(t *testing.T) {
+	s, srv := newFakeConfigServer(t)
+	s.set("/myapp.yml", "port: 8080\nredis:\n  addr: localhost:6379\n")
+
+	c := configfile.NewHTTPReaderWithOptions(srv.URL+"/myapp.yml", configfile.HTTPOptions{Header: authHeader})
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.Equal(t, "localhost:6379", c.String("redis.addr"))
+}
+
+func TestHTTPReaderPerKey(t *testing.T) {
+	s, srv := newFakeConfigServer(t)
+	s.set("/myapp/port", "8080")
+
+	c := configfile.NewHTTPReaderWithOptions(srv.URL+"/myapp/", configfile.HTTPOptions{Header: authHeader, PerKey: true})
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.Equal(t, "", c.String("notfound"))
+	assert.Equal(t, []string{"port"}, c.Keys())
+
+	requests := atomic.LoadInt32(&s.requests)
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.Equal(t, requests, atomic.LoadInt32(&s.requests), "should read from cache")
+
+	require.NoError(t, c.Reload())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&s.notMod))
+
+	s.set("/myapp/port", "8081")
+	require.NoError(t, c.Reload())
+	assert.Equal(t, 8081, c.Int("port"))
+}
+
+func TestHTTPReaderRetry(t *testing.T) {
+	s, srv := newFakeConfigServer(t)
+	s.set("/myapp.json", `{"port": 8080}`)
+	atomic.StoreInt32(&s.fails, 2)
+
+	c := configfile.NewHTTPReaderWithOptions(srv.URL+"/myapp.json", configfile.HTTPOptions{
+		Header:  authHeader,
+		Backoff: time.Millisecond,
+	})
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&s.requests))
+
+	atomic.StoreInt32(&s.fails, 1)
+	c = configfile.NewHTTPReaderWithOptions(srv.URL+"/myapp.json", configfile.HTTPOptions{
+		Header:  authHeader,
+		Retries: -1,
+	}).Fallback(configfile.NewMapReader(map[string]string{"port": "1"}))
+	assert.Equal(t, 1, c.Int("port"))
+	assert.Panics(t, func() { configfile.NewHTTPReader(srv.URL + "/myapp.json").MustInt("port") })
+
+	t.Run("PerKey", func(t *testing.T) {
+		s, srv := newFakeConfigServer(t)
+		s.set("/myapp/port", "8080")
+		atomic.StoreInt32(&s.fails, 2)
+
+		c := configfile.NewHTTPReaderWithOptions(srv.URL+"/myapp", configfile.HTTPOptions{
+			Header:  authHeader,
+			PerKey:  true,
+			Backoff: time.Millisecond,
+		})
+		assert.Equal(t, 0, c.Int("port"))
+		assert.Equal(t, 0, c.Int("port"))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&s.requests), "read must not retry")
+
+		require.NoError(t, c.Reload())
+		assert.Equal(t, int32(3), atomic.LoadInt32(&s.requests))
+		assert.Equal(t, 8080, c.Int("port"))
+	})
+}
+
+func TestHTTPReaderTLS(t *testing.T) {
+	s := &fakeConfigServer{docs: map[string]string{"/myapp.json": `{"port": 8080}`}}
+	srv := httptest.NewUnstartedServer(s)
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	assert.Equal(t, 0, configfile.NewHTTPReaderWithOptions(srv.URL+"/myapp.json", configfile.HTTPOptions{Header: authHeader}).Int("port"))
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	c := configfile.NewHTTPReaderWithOptions(srv.URL+"/myapp.json", configfile.HTTPOptions{
+		Header:    authHeader,
+		TLSConfig: &tls.Config{RootCAs: pool},
+	})
+	assert.Equal(t, 8080, c.Int("port"))
+}
@@ -0,0 +1,106 @@
+package reader
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// NewArgs creates new command line arguments reader
+func NewArgs(args []string) *Args {
+	r := Args{
+		d:         make(map[string]string),
+		requested: make(map[string]bool),
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			// positional argument
+			continue
+		}
+
+		k := strings.TrimPrefix(arg[1:], "-")
+		if p := strings.IndexByte(k, '='); p >= 0 {
+			r.set(k[:p], k[p+1:])
+			continue
+		}
+		if i+1 < len(args) && isArgValue(args[i+1]) {
+			r.set(k, args[i+1])
+			i++
+			continue
+		}
+		if strings.HasPrefix(k, "no-") {
+			r.set(k[3:], "false")
+			continue
+		}
+		r.set(k, "true")
+	}
+	return &r
+}
+
+// isArgValue reports whether arg can be value of previous flag,
+// negative numbers are values (--offset -1)
+func isArgValue(arg string) bool {
+	if !strings.HasPrefix(arg, "-") {
+		return true
+	}
+	_, err := strconv.ParseFloat(arg, 64)
+	return err == nil
+}
+
+// Args reads config from command line arguments
+// in form --key=value, --key value, --key (true) and --no-key (false)
+type Args struct {
+	d map[string]string
+
+	mu        sync.Mutex
+	requested map[string]bool
+}
+
+func (r *Args) set(k, v string) {
+	r.d[FlagName(k)] = v
+}
+
+// Read reads a config
+func (r *Args) Read(name string) ([]byte, error) {
+	n := FlagName(name)
+
+	r.mu.Lock()
+	r.requested[n] = true
+	r.mu.Unlock()
+
+	p, ok := r.d[n]
+	if !ok {
+		return nil, errNotFound
+	}
+	return []byte(p), nil
+}
+
+// Unknown returns sorted names of given flags that never read
+func (r *Args) Unknown() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var xs []string
+	for k := range r.d {
+		if !r.requested[k] {
+			xs = append(xs, k)
+		}
+	}
+	sort.Strings(xs)
+	return xs
+}
+
+// Keys returns names of given flags
+func (r *Args) Keys() ([]string, error) {
+	xs := make([]string, 0, len(r.d))
+	for k := range r.d {
+		xs = append(xs, k)
+	}
+	sort.Strings(xs)
+	return xs, nil
+}
@@ -0,0 +1,286 @@
+package reader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// NewCached creates new reader that keeps last known good values of r in file,
+// values in file are served when r fails
+func NewCached(r source, filename string) *Cached {
+	c := Cached{r: r, filename: filename}
+	c.load()
+	c.sync()
+	return &c
+}
+
+// Cached reads config from source, falls back to values cached in file when source fails
+type Cached struct {
+	r        source
+	filename string
+
+	mu sync.Mutex
+	d  map[string][]byte
+
+	// dirty is true when d has values read after last save
+	dirty      bool
+	updatedAt  time.Time
+	staleSince time.Time
+	err        error
+}
+
+// CacheStatus is the state of cached reader
+type CacheStatus struct {
+	// Stale is true when values are served from cache file because source failed
+	Stale bool
+
+	// StaleSince is when source started failing
+	StaleSince time.Time
+
+	// UpdatedAt is when cache was last written from source
+	UpdatedAt time.Time
+
+	// Err is the last error from source
+	Err error
+}
+
+type cacheFile struct {
+	UpdatedAt time.Time         `json:"updated_at"`
+	Data      map[string][]byte `json:"data"`
+}
+
+// Source returns the cached source
+func (c *Cached) Source() interface{} {
+	return c.r
+}
+
+// Status returns cache status
+func (c *Cached) Status() CacheStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStatus{
+		Stale:      c.err != nil,
+		StaleSince: c.staleSince,
+		UpdatedAt:  c.updatedAt,
+		Err:        c.err,
+	}
+}
+
+func (c *Cached) load() {
+	b, err := os.ReadFile(c.filename)
+	if err != nil {
+		return
+	}
+	var f cacheFile
+	if json.Unmarshal(b, &f) != nil {
+		return
+	}
+	c.d = f.Data
+	c.updatedAt = f.UpdatedAt
+}
+
+// fail marks source failed, c.mu must be held
+func (c *Cached) fail(err error) {
+	if c.err == nil {
+		c.staleSince = time.Now()
+	}
+	c.err = err
+}
+
+// save writes values to file if changed or read since last save, c.mu must be held
+func (c *Cached) save(d map[string][]byte) error {
+	c.err = nil
+	c.staleSince = time.Time{}
+	if c.d != nil && !c.dirty && equalBytesMap(c.d, d) {
+		return nil
+	}
+
+	f := cacheFile{UpdatedAt: time.Now().UTC(), Data: d}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	// write to temp file then rename, so a crash never leaves a partial cache
+	tmp, err := os.CreateTemp(filepath.Dir(c.filename), filepath.Base(c.filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(b)
+	if err == nil {
+		err = tmp.Chmod(0600)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp.Name(), c.filename)
+	if err != nil {
+		return err
+	}
+	c.d = d
+	c.dirty = false
+	c.updatedAt = f.UpdatedAt
+	return nil
+}
+
+// sync reads all keys that source lists, merged with names read before, then saves to file
+func (c *Cached) sync() error {
+	l, ok := c.r.(interface{ Keys() ([]string, error) })
+	if !ok {
+		return nil
+	}
+	keys, err := l.Keys()
+	if err != nil {
+		c.mu.Lock()
+		c.fail(err)
+		c.mu.Unlock()
+		return err
+	}
+
+	c.mu.Lock()
+	names := make(map[string]bool, len(keys)+len(c.d))
+	for _, k := range keys {
+		names[k] = true
+	}
+	for k := range c.d {
+		names[k] = true
+	}
+	c.mu.Unlock()
+
+	// read source without lock, so reads are not blocked by a slow source
+	d := make(map[string][]byte, len(names))
+	for k := range names {
+		p, err := c.r.Read(k)
+		if errors.Is(err, errNotFound) {
+			continue
+		}
+		if err != nil {
+			c.mu.Lock()
+			c.fail(err)
+			c.mu.Unlock()
+			return err
+		}
+		d[k] = p
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.save(d)
+}
+
+// Read reads a config from source, or from cached values when source fails.
+// Values read are kept in memory and saved to file by next Reload or watch update
+func (c *Cached) Read(name string) ([]byte, error) {
+	p, err := c.r.Read(name)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case err == nil:
+		if old, ok := c.d[name]; !ok || string(old) != string(p) {
+			c.d = copyBytesMap(c.d)
+			c.d[name] = p
+			c.dirty = true
+		}
+		return p, nil
+	case errors.Is(err, errNotFound):
+		if _, ok := c.d[name]; ok {
+			c.d = copyBytesMap(c.d)
+			delete(c.d, name)
+			c.dirty = true
+		}
+		return nil, err
+	}
+
+	c.fail(err)
+	if p, ok := c.d[name]; ok {
+		return p, nil
+	}
+	return nil, err
+}
+
+// Keys returns names from source, or from cache file when source fails
+func (c *Cached) Keys() ([]string, error) {
+	if l, ok := c.r.(interface{ Keys() ([]string, error) }); ok {
+		keys, err := l.Keys()
+		if err == nil && c.Status().Err == nil {
+			return keys, nil
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	xs := make([]string, 0, len(c.d))
+	for k := range c.d {
+		xs = append(xs, k)
+	}
+	sort.Strings(xs)
+	return xs, nil
+}
+
+// Reload reloads source then saves values to file,
+// cache file is kept and served when source fails
+func (c *Cached) Reload() error {
+	if rd, ok := c.r.(interface{ Reload() error }); ok {
+		if err := rd.Reload(); err != nil {
+			c.mu.Lock()
+			c.fail(err)
+			c.mu.Unlock()
+			return err
+		}
+	}
+	return c.sync()
+}
+
+// Watch watches source if it can push changes, values are saved to file after changed,
+// cache is marked stale while watch fails
+func (c *Cached) Watch(ctx context.Context, changed func(), failed func(err error)) {
+	w, ok := c.r.(interface {
+		Watch(ctx context.Context, changed func(), failed func(err error))
+	})
+	if !ok {
+		return
+	}
+	w.Watch(ctx, func() {
+		c.sync()
+		changed()
+	}, func(err error) {
+		c.mu.Lock()
+		c.fail(err)
+		c.mu.Unlock()
+		failed(err)
+	})
+}
+
+func copyBytesMap(m map[string][]byte) map[string][]byte {
+	d := make(map[string][]byte, len(m)+1)
+	for k, v := range m {
+		d[k] = v
+	}
+	return d
+}
+
+func equalBytesMap(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if p, ok := b[k]; !ok || string(p) != string(v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,243 @@
+package reader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ConsulOptions configures consul kv reader
+type ConsulOptions struct {
+	// Address is consul agent address, default is http://127.0.0.1:8500
+	Address string
+
+	// Token is acl token
+	Token string
+
+	// Datacenter to query, default is agent's datacenter
+	Datacenter string
+
+	// Prefix is the key prefix, keys under prefix are config names,
+	// e.g. prefix myapp reads myapp/redis/addr as redis/addr
+	Prefix string
+
+	// WaitTime is the max duration of a blocking query, default is 5 minutes
+	WaitTime time.Duration
+
+	// Timeout of a query, blocking queries get WaitTime added, default is 10 seconds
+	Timeout time.Duration
+
+	// Client is http client for requests, should not set Timeout
+	// that cuts blocking queries
+	Client *http.Client
+}
+
+// NewConsul creates new consul kv reader and loads keys under prefix
+func NewConsul(opt ConsulOptions) *Consul {
+	if opt.Address == "" {
+		opt.Address = "http://127.0.0.1:8500"
+	}
+	if !strings.Contains(opt.Address, "://") {
+		opt.Address = "http://" + opt.Address
+	}
+	opt.Address = strings.TrimSuffix(opt.Address, "/")
+	opt.Prefix = strings.Trim(opt.Prefix, "/")
+	if opt.WaitTime <= 0 {
+		opt.WaitTime = 5 * time.Minute
+	}
+	if opt.Timeout <= 0 {
+		opt.Timeout = 10 * time.Second
+	}
+	if opt.Client == nil {
+		opt.Client = &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+	}
+
+	r := Consul{opt: opt}
+	r.d.Store(&consulData{})
+	r.Reload()
+	return &r
+}
+
+// Consul reads config from consul kv
+type Consul struct {
+	opt ConsulOptions
+	d   atomic.Pointer[consulData]
+}
+
+type consulData struct {
+	d     map[string]string
+	index uint64
+	err   error
+}
+
+// Read reads a config
+func (r *Consul) Read(name string) ([]byte, error) {
+	x := r.d.Load()
+	p, ok := x.d[name]
+	if !ok {
+		if x.err != nil {
+			return nil, x.err
+		}
+		return nil, errNotFound
+	}
+	return []byte(p), nil
+}
+
+// Keys returns all names under prefix
+func (r *Consul) Keys() ([]string, error) {
+	x := r.d.Load()
+	if x.err != nil {
+		return nil, x.err
+	}
+	d := x.d
+	xs := make([]string, 0, len(d))
+	for k := range d {
+		xs = append(xs, k)
+	}
+	sort.Strings(xs)
+	return xs, nil
+}
+
+// Reload loads keys again, the old keys are kept if consul can not be queried
+func (r *Consul) Reload() error {
+	x, err := r.query(context.Background(), 0)
+	if err != nil {
+		if old := r.d.Load(); old.d == nil {
+			r.d.Store(&consulData{err: err})
+		}
+		return err
+	}
+	r.d.Store(x)
+	return nil
+}
+
+// Watch runs blocking queries until ctx is done, changed is called after keys changed
+// or consul is reachable again.
+// Failed queries are reported to failed and retried with backoff
+func (r *Consul) Watch(ctx context.Context, changed func(), failed func(err error)) {
+	backoff := time.Second
+	failing := false
+	for ctx.Err() == nil {
+		old := r.d.Load()
+		x, err := r.query(ctx, old.index)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			failing = true
+			failed(err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(backoff):
+			}
+			if backoff < time.Minute {
+				backoff *= 2
+			}
+			continue
+		}
+		backoff = time.Second
+		recovered := failing
+		failing = false
+
+		// consul index can go backward, e.g. after snapshot restore
+		if x.index < old.index {
+			x.index = 0
+		}
+		if x.index == old.index && old.err == nil {
+			if recovered {
+				changed()
+			}
+			continue
+		}
+		r.d.Store(x)
+		if !equalMap(old.d, x.d) || old.err != nil || recovered {
+			changed()
+		}
+	}
+}
+
+type consulKV struct {
+	Key   string
+	Value []byte
+}
+
+// query reads keys under prefix, index > 0 blocks until keys change or wait time passes
+func (r *Consul) query(ctx context.Context, index uint64) (*consulData, error) {
+	timeout := r.opt.Timeout
+	if index > 0 {
+		// consul adds up to wait/16 jitter
+		timeout += r.opt.WaitTime + r.opt.WaitTime/16
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	q := url.Values{}
+	q.Set("recurse", "true")
+	if r.opt.Datacenter != "" {
+		q.Set("dc", r.opt.Datacenter)
+	}
+	if index > 0 {
+		q.Set("index", strconv.FormatUint(index, 10))
+		q.Set("wait", strconv.FormatInt(int64(r.opt.WaitTime/time.Millisecond), 10)+"ms")
+	}
+	p := r.opt.Prefix
+	if p != "" {
+		p += "/"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.opt.Address+"/v1/kv/"+p+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.opt.Token != "" {
+		req.Header.Set("X-Consul-Token", r.opt.Token)
+	}
+
+	resp, err := r.opt.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	x := consulData{d: make(map[string]string)}
+	x.index, _ = strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if resp.StatusCode == http.StatusNotFound {
+		return &x, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reader: consul %s", resp.Status)
+	}
+
+	var kvs []consulKV
+	err = json.NewDecoder(resp.Body).Decode(&kvs)
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range kvs {
+		name := strings.TrimPrefix(strings.TrimPrefix(kv.Key, p), "/")
+		if name == "" || strings.HasSuffix(name, "/") {
+			// folder
+			continue
+		}
+		x.d[name] = string(kv.Value)
+	}
+	return &x, nil
+}
+
+func equalMap(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if p, ok := b[k]; !ok || p != v {
+			return false
+		}
+	}
+	return true
+}
@@ -1,21 +1,118 @@
 package reader
 
 import (
+	"io/fs"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 )
 
 // NewDir creates new dir reader
 func NewDir(dir string) *Dir {
-	return &Dir{dir}
+	return &Dir{Base: dir}
 }
 
 // Dir reads config from directory
 type Dir struct {
 	Base string
+
+	// Skip are sub directories of Base that are not configs, e.g. profile overlays
+	Skip []string
+}
+
+// skipped reports whether name is under a skipped sub directory
+func (r *Dir) skipped(name string) bool {
+	for _, x := range r.Skip {
+		if strings.HasPrefix(filepath.ToSlash(name), x+"/") {
+			return true
+		}
+	}
+	return false
 }
 
 // Read reads a config
 func (r *Dir) Read(name string) ([]byte, error) {
+	if r.skipped(name) {
+		return nil, errNotFound
+	}
 	return ioutil.ReadFile(filepath.Join(r.Base, name))
 }
+
+// Keys returns all names in directory
+func (r *Dir) Keys() ([]string, error) {
+	_, names, err := ListDir(r.Base)
+	if err != nil || len(r.Skip) == 0 {
+		return names, err
+	}
+	xs := names[:0]
+	for _, name := range names {
+		if !r.skipped(name) {
+			xs = append(xs, name)
+		}
+	}
+	return xs, nil
+}
+
+// ListDir lists all files in dir recursively,
+// names prefixed with ".." (kubernetes internals) are skipped.
+// When dir is a kubernetes volume, it returns the target of ..data as base
+// so files can be read without observing a concurrent update half way
+func ListDir(dir string) (base string, names []string, err error) {
+	base = dir
+	if p, err := filepath.EvalSymlinks(filepath.Join(dir, "..data")); err == nil {
+		base = p
+	}
+
+	err = filepath.WalkDir(base, func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == base {
+			return nil
+		}
+		if strings.HasPrefix(e.Name(), "..") {
+			if e.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if e.IsDir() {
+			return nil
+		}
+		stats, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if stats.IsDir() {
+			// symlink to directory
+			return nil
+		}
+
+		name, _ := filepath.Rel(base, path)
+		names = append(names, filepath.ToSlash(name))
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return base, names, nil
+}
+
+// ReadDir reads all files in dir, see ListDir
+func ReadDir(dir string) (map[string][]byte, error) {
+	base, names, err := ListDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	d := make(map[string][]byte, len(names))
+	for _, name := range names {
+		b, err := os.ReadFile(filepath.Join(base, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, err
+		}
+		d[name] = b
+	}
+	return d, nil
+}
@@ -0,0 +1,44 @@
+package reader
+
+import (
+	"io"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// NewDotEnv creates new .env reader
+func NewDotEnv(r io.Reader) *DotEnv {
+	var rd DotEnv
+	rd.decode = godotenv.Parse
+	rd.init(r)
+	return &rd
+}
+
+// NewDotEnvFile creates new reloadable .env reader from file
+func NewDotEnvFile(filename string) *DotEnv {
+	var rd DotEnv
+	rd.decode = godotenv.Parse
+	rd.initFile(filename)
+	return &rd
+}
+
+// DotEnv reads config from .env file without touching process env,
+// names are looked up in upper case like Env
+type DotEnv struct {
+	file
+}
+
+// Read reads a config
+func (r *DotEnv) Read(name string) ([]byte, error) {
+	return r.read(strings.ToUpper(name))
+}
+
+// Keys returns all names in lower case
+func (r *DotEnv) Keys() ([]string, error) {
+	xs := r.keys()
+	for i := range xs {
+		xs[i] = strings.ToLower(xs[i])
+	}
+	return xs, nil
+}
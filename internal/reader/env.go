@@ -22,3 +22,16 @@ func (r *Env) Read(name string) ([]byte, error) {
 	}
 	return []byte(p), nil
 }
+
+// Keys returns all env names in lower case
+func (r *Env) Keys() ([]string, error) {
+	var xs []string
+	for _, e := range os.Environ() {
+		k, _, _ := strings.Cut(e, "=")
+		if k == "" {
+			continue
+		}
+		xs = append(xs, strings.ToLower(k))
+	}
+	return xs, nil
+}
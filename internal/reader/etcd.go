@@ -0,0 +1,387 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EtcdOptions configures etcd reader
+type EtcdOptions struct {
+	// Endpoint is etcd grpc gateway address, default is http://127.0.0.1:2379
+	Endpoint string
+
+	// Username and Password authenticate when etcd auth is enabled
+	Username string
+	Password string
+
+	// Prefix is the key prefix, keys under prefix are config names,
+	// e.g. prefix myapp reads myapp/redis/addr as redis/addr
+	Prefix string
+
+	// Timeout of range and authenticate requests, default is 10 seconds
+	Timeout time.Duration
+
+	// Client is http client for requests, should not set Timeout
+	// that cuts watch streams
+	Client *http.Client
+}
+
+// NewEtcd creates new etcd v3 reader and loads keys under prefix
+func NewEtcd(opt EtcdOptions) *Etcd {
+	if opt.Endpoint == "" {
+		opt.Endpoint = "http://127.0.0.1:2379"
+	}
+	if !strings.Contains(opt.Endpoint, "://") {
+		opt.Endpoint = "http://" + opt.Endpoint
+	}
+	opt.Endpoint = strings.TrimSuffix(opt.Endpoint, "/")
+	if opt.Prefix != "" && !strings.HasSuffix(opt.Prefix, "/") {
+		opt.Prefix += "/"
+	}
+	if opt.Timeout <= 0 {
+		opt.Timeout = 10 * time.Second
+	}
+	if opt.Client == nil {
+		opt.Client = &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+	}
+
+	r := Etcd{opt: opt}
+	r.d.Store(&etcdData{})
+	r.Reload()
+	return &r
+}
+
+// Etcd reads config from etcd v3 through its json grpc gateway
+type Etcd struct {
+	opt EtcdOptions
+
+	// mu serializes updates of d from reload and watch
+	mu sync.Mutex
+	d  atomic.Pointer[etcdData]
+
+	authMu sync.Mutex
+	token  string
+}
+
+type etcdData struct {
+	d        map[string]string
+	revision int64
+	err      error
+}
+
+// Read reads a config
+func (r *Etcd) Read(name string) ([]byte, error) {
+	x := r.d.Load()
+	p, ok := x.d[name]
+	if !ok {
+		if x.err != nil {
+			return nil, x.err
+		}
+		return nil, errNotFound
+	}
+	return []byte(p), nil
+}
+
+// Keys returns all names under prefix
+func (r *Etcd) Keys() ([]string, error) {
+	x := r.d.Load()
+	if x.err != nil {
+		return nil, x.err
+	}
+	d := x.d
+	xs := make([]string, 0, len(d))
+	for k := range d {
+		xs = append(xs, k)
+	}
+	sort.Strings(xs)
+	return xs, nil
+}
+
+// Reload loads keys again, the old keys are kept if etcd can not be queried
+func (r *Etcd) Reload() error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.opt.Timeout)
+	defer cancel()
+	x, err := r.rangeKeys(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		if old := r.d.Load(); old.d == nil {
+			r.d.Store(&etcdData{err: err})
+		}
+		return err
+	}
+	r.d.Store(x)
+	return nil
+}
+
+// Watch watches keys under prefix until ctx is done, changed is called after keys changed
+// or etcd is reachable again.
+// Broken watch streams are reported to failed and reconnected with backoff
+func (r *Etcd) Watch(ctx context.Context, changed func(), failed func(err error)) {
+	backoff := time.Second
+	failing := false
+	for ctx.Err() == nil {
+		if r.d.Load().err != nil || failing {
+			if err := r.Reload(); err != nil {
+				failed(err)
+			} else {
+				failing = false
+				changed()
+			}
+		}
+
+		received, err := r.watch(ctx, changed)
+		if ctx.Err() != nil {
+			return
+		}
+		if received {
+			backoff = time.Second
+		}
+		if err == errEtcdCompacted {
+			// revision is gone, load all keys then watch from there
+			if r.Reload() == nil {
+				changed()
+			}
+			continue
+		}
+		if !failing {
+			failing = true
+			failed(err)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		if backoff < time.Minute {
+			backoff *= 2
+		}
+	}
+}
+
+var errEtcdCompacted = errors.New("reader: etcd revision compacted")
+
+type etcdKV struct {
+	Key         []byte `json:"key"`
+	Value       []byte `json:"value"`
+	ModRevision int64  `json:"mod_revision,string"`
+}
+
+type etcdHeader struct {
+	Revision int64 `json:"revision,string"`
+}
+
+// rangeEnd returns the end of prefix range
+func rangeEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// all keys
+	return []byte{0}
+}
+
+func (r *Etcd) name(key []byte) string {
+	return strings.TrimPrefix(strings.TrimPrefix(string(key), r.opt.Prefix), "/")
+}
+
+func (r *Etcd) keyRange() map[string]interface{} {
+	key := []byte(r.opt.Prefix)
+	if len(key) == 0 {
+		key = []byte{0}
+	}
+	return map[string]interface{}{"key": key, "range_end": rangeEnd(r.opt.Prefix)}
+}
+
+func (r *Etcd) rangeKeys(ctx context.Context) (*etcdData, error) {
+	resp, err := r.post(ctx, "/v3/kv/range", r.keyRange())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Header etcdHeader `json:"header"`
+		KVs    []etcdKV   `json:"kvs"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, err
+	}
+
+	x := etcdData{d: make(map[string]string, len(res.KVs)), revision: res.Header.Revision}
+	for _, kv := range res.KVs {
+		if name := r.name(kv.Key); name != "" {
+			x.d[name] = string(kv.Value)
+		}
+	}
+	return &x, nil
+}
+
+// watch reads watch stream from current revision, reports whether any response received
+func (r *Etcd) watch(ctx context.Context, changed func()) (received bool, err error) {
+	req := r.keyRange()
+	req["start_revision"] = strconv.FormatInt(r.d.Load().revision+1, 10)
+	resp, err := r.post(ctx, "/v3/watch", map[string]interface{}{"create_request": req})
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(resp.Body))
+	for {
+		var res struct {
+			Result struct {
+				Header          etcdHeader `json:"header"`
+				Canceled        bool       `json:"canceled"`
+				CompactRevision int64      `json:"compact_revision,string"`
+				Events          []struct {
+					Type string `json:"type"`
+					KV   etcdKV `json:"kv"`
+				} `json:"events"`
+			} `json:"result"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		err = dec.Decode(&res)
+		if err != nil {
+			return received, err
+		}
+		received = true
+		if res.Error != nil {
+			return received, fmt.Errorf("reader: etcd watch; %s", res.Error.Message)
+		}
+		if res.Result.CompactRevision > 0 {
+			return received, errEtcdCompacted
+		}
+		if res.Result.Canceled {
+			return received, fmt.Errorf("reader: etcd watch canceled")
+		}
+		if len(res.Result.Events) == 0 {
+			continue
+		}
+
+		r.mu.Lock()
+		old := r.d.Load()
+		x := etcdData{d: make(map[string]string, len(old.d)), revision: old.revision}
+		for k, v := range old.d {
+			x.d[k] = v
+		}
+		for _, e := range res.Result.Events {
+			name := r.name(e.KV.Key)
+			if name == "" {
+				continue
+			}
+			if e.Type == "DELETE" {
+				delete(x.d, name)
+			} else {
+				x.d[name] = string(e.KV.Value)
+			}
+			if e.KV.ModRevision > x.revision {
+				x.revision = e.KV.ModRevision
+			}
+		}
+		if res.Result.Header.Revision > x.revision {
+			x.revision = res.Result.Header.Revision
+		}
+		r.d.Store(&x)
+		r.mu.Unlock()
+
+		changed()
+	}
+}
+
+func (r *Etcd) post(ctx context.Context, p string, body interface{}) (*http.Response, error) {
+	resp, err := r.postToken(ctx, p, body, false)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && r.opt.Username != "" {
+		// token expired
+		resp.Body.Close()
+		resp, err = r.postToken(ctx, p, body, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("reader: etcd %s; %s", resp.Status, bytes.TrimSpace(b))
+	}
+	return resp, nil
+}
+
+func (r *Etcd) postToken(ctx context.Context, p string, body interface{}, renew bool) (*http.Response, error) {
+	token, err := r.auth(ctx, renew)
+	if err != nil {
+		return nil, err
+	}
+	return r.do(ctx, p, body, token)
+}
+
+func (r *Etcd) auth(ctx context.Context, renew bool) (string, error) {
+	if r.opt.Username == "" {
+		return "", nil
+	}
+
+	r.authMu.Lock()
+	defer r.authMu.Unlock()
+
+	if r.token != "" && !renew {
+		return r.token, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.opt.Timeout)
+	defer cancel()
+	resp, err := r.do(ctx, "/v3/auth/authenticate", map[string]string{
+		"name":     r.opt.Username,
+		"password": r.opt.Password,
+	}, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("reader: etcd authenticate %s", resp.Status)
+	}
+
+	var res struct {
+		Token string `json:"token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return "", err
+	}
+	r.token = res.Token
+	return r.token, nil
+}
+
+func (r *Etcd) do(ctx context.Context, p string, body interface{}, token string) (*http.Response, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.opt.Endpoint+p, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	return r.opt.Client.Do(req)
+}
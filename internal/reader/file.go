@@ -0,0 +1,85 @@
+package reader
+
+import (
+	"io"
+	"os"
+	"sort"
+	"sync/atomic"
+)
+
+// file holds config decoded from a stream,
+// it can be reloaded when created from filename
+type file struct {
+	filename string
+	decode   func(io.Reader) (map[string]string, error)
+	d        atomic.Pointer[fileData]
+}
+
+// fileData is decoded data with decode error,
+// the error is returned instead of not found so cause of missing data is not lost
+type fileData struct {
+	d   map[string]string
+	err error
+}
+
+func (r *file) init(rd io.Reader) {
+	var x fileData
+	if rd != nil {
+		x.d, x.err = r.decode(rd)
+	}
+	r.d.Store(&x)
+}
+
+func (r *file) initFile(filename string) {
+	r.filename = filename
+	f, err := os.Open(filename)
+	if err != nil {
+		r.d.Store(&fileData{err: err})
+		return
+	}
+	defer f.Close()
+	r.init(f)
+}
+
+func (r *file) read(name string) ([]byte, error) {
+	x := r.d.Load()
+	p, ok := x.d[name]
+	if !ok {
+		if x.err != nil {
+			return nil, x.err
+		}
+		return nil, errNotFound
+	}
+	return []byte(p), nil
+}
+
+// Reload reads the file again, the old data is kept if file can not be decoded.
+// Reader that not created from file does nothing
+func (r *file) Reload() error {
+	if r.filename == "" {
+		return nil
+	}
+
+	f, err := os.Open(r.filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	d, err := r.decode(f)
+	if err != nil {
+		return err
+	}
+	r.d.Store(&fileData{d: d})
+	return nil
+}
+
+func (r *file) keys() []string {
+	d := r.d.Load().d
+	xs := make([]string, 0, len(d))
+	for k := range d {
+		xs = append(xs, k)
+	}
+	sort.Strings(xs)
+	return xs
+}
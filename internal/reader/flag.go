@@ -0,0 +1,52 @@
+package reader
+
+import (
+	"flag"
+	"strings"
+)
+
+// NewFlag creates new flag reader
+func NewFlag(fs *flag.FlagSet) *Flag {
+	return &Flag{fs}
+}
+
+// Flag reads config from explicitly set flags
+type Flag struct {
+	fs *flag.FlagSet
+}
+
+// Read reads a config, flags that are not set on command line are not found
+func (r *Flag) Read(name string) ([]byte, error) {
+	for _, n := range []string{name, FlagName(name)} {
+		var (
+			p  string
+			ok bool
+		)
+		r.fs.Visit(func(f *flag.Flag) {
+			if f.Name == n {
+				p, ok = f.Value.String(), true
+			}
+		})
+		if ok {
+			return []byte(p), nil
+		}
+	}
+	return nil, errNotFound
+}
+
+var flagNameReplacer = strings.NewReplacer("_", "-", ".", "-", "/", "-")
+
+// FlagName converts config name to flag name,
+// e.g. redis_addr and redis/addr become redis-addr
+func FlagName(name string) string {
+	return flagNameReplacer.Replace(strings.ToLower(name))
+}
+
+// Keys returns names of explicitly set flags
+func (r *Flag) Keys() ([]string, error) {
+	var xs []string
+	r.fs.Visit(func(f *flag.Flag) {
+		xs = append(xs, f.Name)
+	})
+	return xs, nil
+}
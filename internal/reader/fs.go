@@ -0,0 +1,49 @@
+package reader
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// NewFS creates new fs reader
+func NewFS(fsys fs.FS) *FS {
+	return &FS{fsys}
+}
+
+// FS reads config from fs.FS, each file is a config like Dir
+type FS struct {
+	FS fs.FS
+}
+
+// Read reads a config
+func (r *FS) Read(name string) ([]byte, error) {
+	return fs.ReadFile(r.FS, name)
+}
+
+// Keys returns all file names,
+// names prefixed with ".." (kubernetes internals) are skipped
+func (r *FS) Keys() ([]string, error) {
+	var xs []string
+	err := fs.WalkDir(r.FS, ".", func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == "." {
+			return nil
+		}
+		if strings.HasPrefix(e.Name(), "..") {
+			if e.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !e.IsDir() {
+			xs = append(xs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return xs, nil
+}
@@ -0,0 +1,277 @@
+package reader
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPOptions configures http reader
+type HTTPOptions struct {
+	// Format of document, "json" or "yaml",
+	// detected from Content-Type then url extension if empty, default is json
+	Format string
+
+	// PerKey fetches each config from <url>/<name> with body as value,
+	// instead of one document
+	PerKey bool
+
+	// Header is added to every request, e.g. Authorization
+	Header http.Header
+
+	// Timeout of each request, default is 10 seconds
+	Timeout time.Duration
+
+	// Retries is the number of retries after a request failed
+	// with network error or 5xx or 429 status, default is 2, negative disables retry.
+	// Per key reads never retry, only Reload does
+	Retries int
+
+	// Backoff is the wait before first retry, doubled for each next retry,
+	// default is 100ms
+	Backoff time.Duration
+
+	// TLSConfig is used by default client
+	TLSConfig *tls.Config
+
+	// Client is http client for requests, Timeout and TLSConfig are ignored when set
+	Client *http.Client
+
+	// TTL is how long per key values are cached, zero caches until reload
+	TTL time.Duration
+}
+
+// NewHTTP creates new http reader, document is loaded at once
+func NewHTTP(url string, opt HTTPOptions) *HTTP {
+	if opt.Timeout <= 0 {
+		opt.Timeout = 10 * time.Second
+	}
+	if opt.Retries == 0 {
+		opt.Retries = 2
+	}
+	if opt.Backoff <= 0 {
+		opt.Backoff = 100 * time.Millisecond
+	}
+	if opt.Client == nil {
+		tr := http.DefaultTransport.(*http.Transport).Clone()
+		tr.TLSClientConfig = opt.TLSConfig
+		opt.Client = &http.Client{Timeout: opt.Timeout, Transport: tr}
+	}
+
+	r := HTTP{url: url, opt: opt}
+	if opt.PerKey {
+		r.url = strings.TrimSuffix(url, "/")
+		r.remote = remote{
+			fetch:  func(name string) (map[string]string, time.Duration, error) { return r.fetchKey(name, 0) },
+			reload: func(name string) (map[string]string, time.Duration, error) { return r.fetchKey(name, r.opt.Retries) },
+			ttl:    opt.TTL,
+			now:    time.Now,
+		}
+		return &r
+	}
+	r.d.Store(&httpDocument{})
+	r.Reload()
+	return &r
+}
+
+// HTTP reads config from http endpoint
+type HTTP struct {
+	remote
+	url string
+	opt HTTPOptions
+
+	// d is the document
+	d atomic.Pointer[httpDocument]
+
+	// etags of per key values
+	etags sync.Map // map[string]httpDocument
+}
+
+type httpDocument struct {
+	d    map[string]string
+	etag string
+	err  error
+}
+
+// Read reads a config
+func (r *HTTP) Read(name string) ([]byte, error) {
+	if r.opt.PerKey {
+		return r.read(name, name)
+	}
+
+	x := r.d.Load()
+	p, ok := x.d[name]
+	if !ok {
+		if x.err != nil {
+			return nil, x.err
+		}
+		return nil, errNotFound
+	}
+	return []byte(p), nil
+}
+
+// Keys returns all names in document, per key reader returns fetched names
+func (r *HTTP) Keys() ([]string, error) {
+	var xs []string
+	if r.opt.PerKey {
+		r.etags.Range(func(k, _ interface{}) bool {
+			xs = append(xs, k.(string))
+			return true
+		})
+	} else {
+		x := r.d.Load()
+		if x.err != nil {
+			return nil, x.err
+		}
+		for k := range x.d {
+			xs = append(xs, k)
+		}
+	}
+	sort.Strings(xs)
+	return xs, nil
+}
+
+// Reload fetches document again with If-None-Match,
+// the old document is kept if it is not modified or can not be fetched
+func (r *HTTP) Reload() error {
+	if r.opt.PerKey {
+		return r.remote.Reload()
+	}
+
+	old := r.d.Load()
+	resp, body, err := r.get(r.url, old.etag, r.opt.Retries)
+	if err != nil {
+		if old.d == nil {
+			r.d.Store(&httpDocument{err: err})
+		}
+		return err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+
+	var d map[string]string
+	switch r.format(resp) {
+	case "yaml":
+		d, err = DecodeYAML(bytes.NewReader(body))
+	default:
+		d, err = DecodeJSON(bytes.NewReader(body))
+	}
+	if err != nil {
+		err = fmt.Errorf("reader: invalid document from %s; %w", r.url, err)
+		if old.d == nil {
+			r.d.Store(&httpDocument{err: err})
+		}
+		return err
+	}
+	r.d.Store(&httpDocument{d: d, etag: resp.Header.Get("ETag")})
+	return nil
+}
+
+func (r *HTTP) format(resp *http.Response) string {
+	if r.opt.Format != "" {
+		return strings.ToLower(r.opt.Format)
+	}
+	if ct := resp.Header.Get("Content-Type"); strings.Contains(ct, "yaml") {
+		return "yaml"
+	} else if strings.Contains(ct, "json") {
+		return "json"
+	}
+	switch path.Ext(resp.Request.URL.Path) {
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	return "json"
+}
+
+// fetchKey fetches value of name, retries is used only by Reload
+// to not block reads with backoff
+func (r *HTTP) fetchKey(name string, retries int) (map[string]string, time.Duration, error) {
+	var old httpDocument
+	if p, ok := r.etags.Load(name); ok {
+		old = p.(httpDocument)
+	}
+
+	resp, body, err := r.get(r.url+"/"+name, old.etag, retries)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return old.d, 0, nil
+	}
+
+	d := map[string]string{name: string(body)}
+	r.etags.Store(name, httpDocument{d: d, etag: resp.Header.Get("ETag")})
+	return d, 0, nil
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+}
+
+// get requests url with retries, response body is read and closed,
+// not found is returned as errNotFound and other non 2xx or 304 status as error
+func (r *HTTP) get(url, etag string, retries int) (*http.Response, []byte, error) {
+	var (
+		resp *http.Response
+		body []byte
+		err  error
+	)
+	backoff := r.opt.Backoff
+	for i := 0; ; i++ {
+		resp, body, err = r.do(url, etag)
+		if !retryable(resp, err) || i >= retries {
+			break
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil, errNotFound
+	case resp.StatusCode == http.StatusNotModified:
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, nil, fmt.Errorf("reader: http %s from %s", resp.Status, url)
+	}
+	return resp, body, nil
+}
+
+func (r *HTTP) do(url, etag string) (*http.Response, []byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range r.opt.Header {
+		req.Header[k] = v
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := r.opt.Client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
@@ -0,0 +1,86 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// NewJSON creates new json reader
+func NewJSON(r io.Reader) *JSON {
+	var rd JSON
+	rd.decode = DecodeJSON
+	rd.init(r)
+	return &rd
+}
+
+// NewJSONFile creates new reloadable json reader from file
+func NewJSONFile(filename string) *JSON {
+	var rd JSON
+	rd.decode = DecodeJSON
+	rd.initFile(filename)
+	return &rd
+}
+
+// JSON reads config from json file
+type JSON struct {
+	file
+}
+
+// Read reads a config
+func (r *JSON) Read(name string) ([]byte, error) {
+	return r.read(name)
+}
+
+// Keys returns all names
+func (r *JSON) Keys() ([]string, error) {
+	return r.keys(), nil
+}
+
+// DecodeJSON decodes json object into string map,
+// numbers and booleans are kept as written, null becomes empty string,
+// nested objects and arrays are flatten with "." (redis.addr, hosts.0).
+// sops encrypted document is decrypted with DefaultSOPSDataKey
+func DecodeJSON(r io.Reader) (map[string]string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if IsSOPS(b) {
+		return DecodeSOPS(b, DefaultSOPSDataKey)
+	}
+
+	var raw map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	err = dec.Decode(&raw)
+	if err != nil {
+		return nil, err
+	}
+
+	d := make(map[string]string, len(raw))
+	flattenJSON(d, "", raw)
+	return d, nil
+}
+
+func flattenJSON(d map[string]string, name string, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		d[name] = ""
+	case string:
+		d[name] = v
+	case json.Number:
+		d[name] = v.String()
+	case bool:
+		d[name] = strconv.FormatBool(v)
+	case map[string]interface{}:
+		for k, x := range v {
+			flattenJSON(d, joinName(name, k), x)
+		}
+	case []interface{}:
+		for i, x := range v {
+			flattenJSON(d, joinName(name, strconv.Itoa(i)), x)
+		}
+	}
+}
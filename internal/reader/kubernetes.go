@@ -0,0 +1,249 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// KubernetesOptions configures kubernetes reader
+type KubernetesOptions struct {
+	// Name of ConfigMap or Secret
+	Name string
+
+	// Namespace of the object, default is namespace of kubeconfig context
+	// or pod namespace in cluster
+	Namespace string
+
+	// Secret reads a Secret instead of a ConfigMap
+	Secret bool
+
+	// Kubeconfig is path to kubeconfig file,
+	// default is KUBECONFIG env or ~/.kube/config, then in-cluster service account
+	Kubeconfig string
+
+	// Client is used instead of a client from kubeconfig,
+	// e.g. fake clientset in tests
+	Client kubernetes.Interface
+
+	// Timeout of get requests, default is 10 seconds
+	Timeout time.Duration
+}
+
+// NewKubernetes creates new kubernetes ConfigMap or Secret reader and loads the object
+func NewKubernetes(opt KubernetesOptions) *Kubernetes {
+	if opt.Timeout <= 0 {
+		opt.Timeout = 10 * time.Second
+	}
+
+	r := Kubernetes{opt: opt}
+	if r.opt.Client == nil {
+		r.opt.Client, r.opt.Namespace, r.err = kubernetesClient(opt.Kubeconfig, opt.Namespace)
+	}
+	if r.opt.Namespace == "" {
+		r.opt.Namespace = metav1.NamespaceDefault
+	}
+	r.d.Store(&kubernetesData{})
+	r.Reload()
+	return &r
+}
+
+func kubernetesClient(kubeconfig, namespace string) (kubernetes.Interface, string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{})
+
+	c, err := config.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	if namespace == "" {
+		namespace, _, _ = config.Namespace()
+	}
+	client, err := kubernetes.NewForConfig(c)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, namespace, nil
+}
+
+// Kubernetes reads config from keys of a ConfigMap or Secret through kubernetes api
+type Kubernetes struct {
+	opt KubernetesOptions
+
+	// err is the error of creating client from kubeconfig
+	err error
+
+	// mu serializes updates of d from reload and watch
+	mu sync.Mutex
+	d  atomic.Pointer[kubernetesData]
+}
+
+type kubernetesData struct {
+	d   map[string]string
+	err error
+}
+
+// Read reads a config
+func (r *Kubernetes) Read(name string) ([]byte, error) {
+	x := r.d.Load()
+	p, ok := x.d[name]
+	if !ok {
+		if x.err != nil {
+			return nil, x.err
+		}
+		return nil, errNotFound
+	}
+	return []byte(p), nil
+}
+
+// Keys returns all keys of the object
+func (r *Kubernetes) Keys() ([]string, error) {
+	x := r.d.Load()
+	if x.err != nil {
+		return nil, x.err
+	}
+	d := x.d
+	xs := make([]string, 0, len(d))
+	for k := range d {
+		xs = append(xs, k)
+	}
+	sort.Strings(xs)
+	return xs, nil
+}
+
+// decodeObject returns keys of ConfigMap data and binaryData or Secret data
+func decodeObject(obj interface{}) map[string]string {
+	d := make(map[string]string)
+	switch o := obj.(type) {
+	case *corev1.ConfigMap:
+		for k, v := range o.Data {
+			d[k] = v
+		}
+		for k, v := range o.BinaryData {
+			d[k] = string(v)
+		}
+	case *corev1.Secret:
+		for k, v := range o.Data {
+			d[k] = string(v)
+		}
+	}
+	return d
+}
+
+// get gets the object, not found object has no keys
+func (r *Kubernetes) get(ctx context.Context) (map[string]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.opt.Timeout)
+	defer cancel()
+
+	var (
+		obj interface{}
+		err error
+	)
+	core := r.opt.Client.CoreV1()
+	if r.opt.Secret {
+		obj, err = core.Secrets(r.opt.Namespace).Get(ctx, r.opt.Name, metav1.GetOptions{})
+	} else {
+		obj, err = core.ConfigMaps(r.opt.Namespace).Get(ctx, r.opt.Name, metav1.GetOptions{})
+	}
+	if apierrors.IsNotFound(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeObject(obj), nil
+}
+
+// Reload loads the object again, the old keys are kept if api can not be queried
+func (r *Kubernetes) Reload() error {
+	d, err := r.get(context.Background())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		if old := r.d.Load(); old.d == nil {
+			r.d.Store(&kubernetesData{err: err})
+		}
+		return err
+	}
+	r.d.Store(&kubernetesData{d: d})
+	return nil
+}
+
+// Watch runs an informer on the object until ctx is done,
+// changed is called after keys changed.
+// Failed list and watch requests are reported to failed and retried by the informer with backoff
+func (r *Kubernetes) Watch(ctx context.Context, changed func(), failed func(err error)) {
+	if r.err != nil {
+		failed(r.err)
+		return
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(r.opt.Client, 0,
+		informers.WithNamespace(r.opt.Namespace),
+		informers.WithTweakListOptions(func(opt *metav1.ListOptions) {
+			opt.FieldSelector = fields.OneTermEqualSelector("metadata.name", r.opt.Name).String()
+		}),
+	)
+	var inf cache.SharedIndexInformer
+	if r.opt.Secret {
+		inf = factory.Core().V1().Secrets().Informer()
+	} else {
+		inf = factory.Core().V1().ConfigMaps().Informer()
+	}
+
+	inf.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		// closed or expired watches are restarted, not failures
+		if ctx.Err() != nil || errors.Is(err, io.EOF) || apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+			return
+		}
+		failed(err)
+	})
+
+	// keys are read from informer store, so events can be handled in any order
+	key := r.opt.Namespace + "/" + r.opt.Name
+	update := func() {
+		r.mu.Lock()
+		old := r.d.Load()
+		x := kubernetesData{d: map[string]string{}}
+		if obj, ok, _ := inf.GetStore().GetByKey(key); ok {
+			x.d = decodeObject(obj)
+		}
+		r.d.Store(&x)
+		r.mu.Unlock()
+
+		if !equalMap(old.d, x.d) || old.err != nil {
+			changed()
+		}
+	}
+	inf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { update() },
+		UpdateFunc: func(_, _ interface{}) { update() },
+		DeleteFunc: func(interface{}) { update() },
+	})
+
+	go inf.Run(ctx.Done())
+	if cache.WaitForCacheSync(ctx.Done(), inf.HasSynced) {
+		// object deleted before the informer started has no event
+		update()
+	}
+	<-ctx.Done()
+}
@@ -0,0 +1,67 @@
+package reader
+
+import (
+	"sort"
+	"sync"
+)
+
+// NewMap creates new map reader
+func NewMap(m map[string]string) *Map {
+	r := Map{d: make(map[string]string, len(m))}
+	for k, v := range m {
+		r.d[k] = v
+	}
+	return &r
+}
+
+// Map reads config from in-memory map
+type Map struct {
+	mu sync.RWMutex
+	d  map[string]string
+}
+
+// Read reads a config
+func (r *Map) Read(name string) ([]byte, error) {
+	r.mu.RLock()
+	p, ok := r.d[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, errNotFound
+	}
+	return []byte(p), nil
+}
+
+// Lookup returns value of name and whether it is set
+func (r *Map) Lookup(name string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.d[name]
+	return p, ok
+}
+
+// Set sets value of name
+func (r *Map) Set(name, value string) {
+	r.mu.Lock()
+	r.d[name] = value
+	r.mu.Unlock()
+}
+
+// Delete deletes name
+func (r *Map) Delete(name string) {
+	r.mu.Lock()
+	delete(r.d, name)
+	r.mu.Unlock()
+}
+
+// Keys returns all names
+func (r *Map) Keys() ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	xs := make([]string, 0, len(r.d))
+	for k := range r.d {
+		xs = append(xs, k)
+	}
+	sort.Strings(xs)
+	return xs, nil
+}
@@ -0,0 +1,199 @@
+package reader
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// remote error retry backoff
+const (
+	remoteMinBackoff = time.Second
+	remoteMaxBackoff = time.Minute
+)
+
+// remote caches documents fetched from a remote source by key,
+// fetch returns flatten data of the document and how long it can be cached,
+// zero ttl uses the default ttl, and zero default ttl caches until reload,
+// reload is used instead of fetch by Reload when set.
+//
+// Failed fetches are cached and retried with backoff.
+// Only one fetch of a key runs at a time, outside the lock,
+// other reads get the last good document while a refetch is pending
+type remote struct {
+	fetch  func(key string) (d map[string]string, ttl time.Duration, err error)
+	reload func(key string) (d map[string]string, ttl time.Duration, err error)
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu sync.Mutex
+	d  map[string]*remoteEntry
+}
+
+type remoteEntry struct {
+	d   map[string]string
+	err error
+
+	// exp is when document expires, zero never expires
+	exp time.Time
+
+	// good is the last document fetched without error
+	good map[string]string
+
+	// retryAt is when a failed fetch can be retried
+	retryAt time.Time
+	backoff time.Duration
+
+	// fetching is closed when the running fetch finishes
+	fetching chan struct{}
+}
+
+// fresh reports whether e can be served without fetching
+func (r *remote) fresh(e *remoteEntry) bool {
+	now := r.now()
+	if e.err != nil && !errors.Is(e.err, errNotFound) {
+		return now.Before(e.retryAt)
+	}
+	return e.exp.IsZero() || now.Before(e.exp)
+}
+
+// get returns document of key from cache, fetch when not cached or expired
+func (r *remote) get(key string) (map[string]string, error) {
+	r.mu.Lock()
+	if r.d == nil {
+		r.d = make(map[string]*remoteEntry)
+	}
+	e := r.d[key]
+	if e != nil && e.fetching != nil && e.good == nil {
+		// first fetch is running, wait for it
+		ch := e.fetching
+		r.mu.Unlock()
+		<-ch
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		e = r.d[key]
+		return e.d, e.err
+	}
+	if e != nil && r.fresh(e) {
+		r.mu.Unlock()
+		return e.d, e.err
+	}
+	if e != nil && e.fetching != nil {
+		r.mu.Unlock()
+		return e.good, nil
+	}
+	r.mu.Unlock()
+
+	e = r.refresh(key, r.fetch)
+	return e.d, e.err
+}
+
+// refresh fetches key, or waits for the running fetch of key
+func (r *remote) refresh(key string, fetch func(string) (map[string]string, time.Duration, error)) *remoteEntry {
+	r.mu.Lock()
+	if r.d == nil {
+		r.d = make(map[string]*remoteEntry)
+	}
+	e := r.d[key]
+	if e == nil {
+		e = &remoteEntry{}
+		r.d[key] = e
+	}
+	if ch := e.fetching; ch != nil {
+		r.mu.Unlock()
+		<-ch
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.d[key]
+	}
+	ch := make(chan struct{})
+	e.fetching = ch
+	r.mu.Unlock()
+
+	d, ttl, err := fetch(key)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer close(ch)
+
+	x := remoteEntry{d: d, err: err, good: e.good}
+	switch {
+	case err == nil:
+		x.good = d
+		fallthrough
+	case errors.Is(err, errNotFound):
+		if ttl <= 0 {
+			ttl = r.ttl
+		}
+		if ttl > 0 {
+			x.exp = r.now().Add(ttl)
+		}
+	default:
+		x.backoff = remoteMinBackoff
+		if e.backoff > 0 {
+			x.backoff = e.backoff * 2
+		}
+		if x.backoff > remoteMaxBackoff {
+			x.backoff = remoteMaxBackoff
+		}
+		x.retryAt = r.now().Add(x.backoff)
+	}
+	r.d[key] = &x
+	return &x
+}
+
+// read reads field name from document key
+func (r *remote) read(key, name string) ([]byte, error) {
+	d, err := r.get(key)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := d[name]
+	if !ok {
+		return nil, errNotFound
+	}
+	return []byte(p), nil
+}
+
+// keys returns field names of document key
+func (r *remote) keys(key string) ([]string, error) {
+	d, err := r.get(key)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	xs := make([]string, 0, len(d))
+	for k := range d {
+		xs = append(xs, k)
+	}
+	sort.Strings(xs)
+	return xs, nil
+}
+
+// Reload fetches all cached documents again,
+// a document that can not be fetched is retried with backoff on read
+func (r *remote) Reload() error {
+	r.mu.Lock()
+	keys := make([]string, 0, len(r.d))
+	for key := range r.d {
+		keys = append(keys, key)
+	}
+	r.mu.Unlock()
+
+	fetch := r.reload
+	if fetch == nil {
+		fetch = r.fetch
+	}
+	var errs []error
+	for _, key := range keys {
+		e := r.refresh(key, fetch)
+		if e.err != nil && !errors.Is(e.err, errNotFound) {
+			errs = append(errs, e.err)
+		}
+	}
+	return errors.Join(errs...)
+}
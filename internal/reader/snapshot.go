@@ -0,0 +1,51 @@
+package reader
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// NewDirSnapshot creates new dir snapshot reader and loads dir into memory
+func NewDirSnapshot(dir string) *DirSnapshot {
+	r := DirSnapshot{Base: dir}
+	r.d.Store(&map[string][]byte{})
+	r.Reload()
+	return &r
+}
+
+// DirSnapshot reads config from in-memory copy of directory
+type DirSnapshot struct {
+	Base string
+	d    atomic.Pointer[map[string][]byte]
+}
+
+// Read reads a config
+func (r *DirSnapshot) Read(name string) ([]byte, error) {
+	p, ok := (*r.d.Load())[name]
+	if !ok {
+		return nil, errNotFound
+	}
+	return append([]byte{}, p...), nil
+}
+
+// Reload loads directory then swaps the whole snapshot,
+// the old snapshot is kept if directory can not be read
+func (r *DirSnapshot) Reload() error {
+	d, err := ReadDir(r.Base)
+	if err != nil {
+		return err
+	}
+	r.d.Store(&d)
+	return nil
+}
+
+// Keys returns all names in snapshot
+func (r *DirSnapshot) Keys() ([]string, error) {
+	d := *r.d.Load()
+	xs := make([]string, 0, len(d))
+	for k := range d {
+		xs = append(xs, k)
+	}
+	sort.Strings(xs)
+	return xs, nil
+}
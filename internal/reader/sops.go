@@ -0,0 +1,521 @@
+package reader
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgparmor "github.com/ProtonMail/go-crypto/openpgp/armor"
+	"gopkg.in/yaml.v3"
+)
+
+// SOPSMasterKey is an encrypted data key in sops metadata
+type SOPSMasterKey struct {
+	// Type is one of "age", "pgp", "kms", "gcp_kms", "azure_kv" and "hc_vault"
+	Type string
+
+	// Recipient identifies the master key,
+	// age recipient, pgp fingerprint, kms arn, gcp kms resource id,
+	// azure key vault key url or vault transit key url
+	Recipient string
+
+	// EncryptedKey is the data key encrypted with master key
+	EncryptedKey string
+}
+
+// SOPSDataKey decrypts sops data key from master keys
+type SOPSDataKey func(keys []SOPSMasterKey) ([]byte, error)
+
+// DefaultSOPSDataKey decrypts data key with age identities
+// from SOPS_AGE_KEY, SOPS_AGE_KEY_FILE or sops/age/keys.txt in user config dir,
+// the same places sops looks up, then with gpg command for pgp recipients
+func DefaultSOPSDataKey(keys []SOPSMasterKey) ([]byte, error) {
+	var ids []age.Identity
+	if p := os.Getenv("SOPS_AGE_KEY"); p != "" {
+		xs, err := age.ParseIdentities(strings.NewReader(p))
+		if err != nil {
+			return nil, fmt.Errorf("reader: invalid SOPS_AGE_KEY; %w", err)
+		}
+		ids = append(ids, xs...)
+	}
+
+	fn := os.Getenv("SOPS_AGE_KEY_FILE")
+	if fn == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			fn = filepath.Join(dir, "sops", "age", "keys.txt")
+		}
+	}
+	if b, err := os.ReadFile(fn); err == nil {
+		xs, err := age.ParseIdentities(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("reader: invalid age key file %s; %w", fn, err)
+		}
+		ids = append(ids, xs...)
+	}
+
+	b, err := AgeSOPSDataKey(ids...)(keys)
+	if err == nil || !hasSOPSKey(keys, "pgp") {
+		return b, err
+	}
+	if _, lerr := exec.LookPath("gpg"); lerr != nil {
+		return nil, err
+	}
+	return GPGSOPSDataKey(keys)
+}
+
+func hasSOPSKey(keys []SOPSMasterKey, typ string) bool {
+	for _, k := range keys {
+		if k.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// PGPSOPSDataKey creates sops data key decrypter from pgp private keys,
+// encrypted private keys must be decrypted before
+func PGPSOPSDataKey(keyring openpgp.EntityList) SOPSDataKey {
+	return func(keys []SOPSMasterKey) ([]byte, error) {
+		if len(keyring) == 0 {
+			return nil, errors.New("reader: sops: no pgp key")
+		}
+		for _, k := range keys {
+			if k.Type != "pgp" {
+				continue
+			}
+			block, err := pgparmor.Decode(strings.NewReader(k.EncryptedKey))
+			if err != nil {
+				continue
+			}
+			md, err := openpgp.ReadMessage(block.Body, keyring, nil, nil)
+			if err != nil {
+				continue
+			}
+			return io.ReadAll(md.UnverifiedBody)
+		}
+		return nil, errors.New("reader: sops: no pgp key matches recipients")
+	}
+}
+
+// GPGSOPSDataKey decrypts sops data key with gpg command,
+// keys in user keyring and gpg-agent are used, like sops does
+func GPGSOPSDataKey(keys []SOPSMasterKey) ([]byte, error) {
+	var errs []error
+	for _, k := range keys {
+		if k.Type != "pgp" {
+			continue
+		}
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command("gpg", "--batch", "--quiet", "--no-tty", "--decrypt")
+		cmd.Stdin = strings.NewReader(k.EncryptedKey)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			errs = append(errs, fmt.Errorf("reader: sops: gpg decrypt %s; %w; %s", k.Recipient, err, bytes.TrimSpace(stderr.Bytes())))
+			continue
+		}
+		return stdout.Bytes(), nil
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("reader: sops: no pgp recipient")
+	}
+	return nil, errors.Join(errs...)
+}
+
+// AgeSOPSDataKey creates sops data key decrypter from age identities
+func AgeSOPSDataKey(ids ...age.Identity) SOPSDataKey {
+	return func(keys []SOPSMasterKey) ([]byte, error) {
+		if len(ids) == 0 {
+			return nil, errors.New("reader: sops: no age identity")
+		}
+		for _, k := range keys {
+			if k.Type != "age" {
+				continue
+			}
+			r, err := age.Decrypt(armor.NewReader(strings.NewReader(k.EncryptedKey)), ids...)
+			if err != nil {
+				continue
+			}
+			return io.ReadAll(r)
+		}
+		return nil, errors.New("reader: sops: no age identity matches recipients")
+	}
+}
+
+// IsSOPS reports whether b is a sops encrypted yaml or json document
+func IsSOPS(b []byte) bool {
+	var doc struct {
+		SOPS struct {
+			MAC string `yaml:"mac"`
+		} `yaml:"sops"`
+	}
+	yaml.Unmarshal(b, &doc)
+	return doc.SOPS.MAC != ""
+}
+
+type sopsMetadata struct {
+	KeyGroups    []sopsKeyGroup `yaml:"key_groups"`
+	sopsKeyGroup `yaml:",inline"`
+
+	ShamirThreshold         int    `yaml:"shamir_threshold"`
+	LastModified            string `yaml:"lastmodified"`
+	MAC                     string `yaml:"mac"`
+	UnencryptedSuffix       string `yaml:"unencrypted_suffix"`
+	EncryptedSuffix         string `yaml:"encrypted_suffix"`
+	UnencryptedRegex        string `yaml:"unencrypted_regex"`
+	EncryptedRegex          string `yaml:"encrypted_regex"`
+	UnencryptedCommentRegex string `yaml:"unencrypted_comment_regex"`
+	EncryptedCommentRegex   string `yaml:"encrypted_comment_regex"`
+	MACOnlyEncrypted        bool   `yaml:"mac_only_encrypted"`
+}
+
+type sopsKeyGroup struct {
+	Age []struct {
+		Recipient string `yaml:"recipient"`
+		Enc       string `yaml:"enc"`
+	} `yaml:"age"`
+	PGP []struct {
+		Fingerprint string `yaml:"fp"`
+		Enc         string `yaml:"enc"`
+	} `yaml:"pgp"`
+	KMS []struct {
+		ARN string `yaml:"arn"`
+		Enc string `yaml:"enc"`
+	} `yaml:"kms"`
+	GCPKMS []struct {
+		ResourceID string `yaml:"resource_id"`
+		Enc        string `yaml:"enc"`
+	} `yaml:"gcp_kms"`
+	AzureKV []struct {
+		VaultURL string `yaml:"vault_url"`
+		Name     string `yaml:"name"`
+		Version  string `yaml:"version"`
+		Enc      string `yaml:"enc"`
+	} `yaml:"azure_kv"`
+	HCVault []struct {
+		VaultAddress string `yaml:"vault_address"`
+		EnginePath   string `yaml:"engine_path"`
+		KeyName      string `yaml:"key_name"`
+		Enc          string `yaml:"enc"`
+	} `yaml:"hc_vault"`
+}
+
+func (g *sopsKeyGroup) masterKeys() []SOPSMasterKey {
+	var xs []SOPSMasterKey
+	for _, k := range g.Age {
+		xs = append(xs, SOPSMasterKey{"age", k.Recipient, k.Enc})
+	}
+	for _, k := range g.PGP {
+		xs = append(xs, SOPSMasterKey{"pgp", k.Fingerprint, k.Enc})
+	}
+	for _, k := range g.KMS {
+		xs = append(xs, SOPSMasterKey{"kms", k.ARN, k.Enc})
+	}
+	for _, k := range g.GCPKMS {
+		xs = append(xs, SOPSMasterKey{"gcp_kms", k.ResourceID, k.Enc})
+	}
+	for _, k := range g.AzureKV {
+		xs = append(xs, SOPSMasterKey{"azure_kv", k.VaultURL + "/keys/" + k.Name + "/" + k.Version, k.Enc})
+	}
+	for _, k := range g.HCVault {
+		xs = append(xs, SOPSMasterKey{"hc_vault", k.VaultAddress + "/v1/" + k.EnginePath + "/keys/" + k.KeyName, k.Enc})
+	}
+	return xs
+}
+
+// sopsMACOnlyEncryptedInit initializes mac when mac_only_encrypted is set, same as sops
+var sopsMACOnlyEncryptedInit = []byte{0x8a, 0x3f, 0xd2, 0xad, 0x54, 0xce, 0x66, 0x52, 0x7b, 0x10, 0x34, 0xf3, 0xd1, 0x47, 0xbe, 0xb, 0xb, 0x97, 0x5b, 0x3b, 0xf4, 0x4f, 0x72, 0xc6, 0xfd, 0xad, 0xec, 0x81, 0x76, 0xf2, 0x7d, 0x69}
+
+// DecodeSOPS decrypts sops encrypted yaml or json document into string map,
+// nested keys are joined with ".".
+// No value is returned unless the document mac is verified
+func DecodeSOPS(b []byte, dataKey SOPSDataKey) (map[string]string, error) {
+	var root yaml.Node
+	err := yaml.Unmarshal(b, &root)
+	if err != nil {
+		return nil, err
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("reader: sops: document is not a mapping")
+	}
+	doc := root.Content[0]
+
+	var (
+		meta sopsMetadata
+		tree []*yaml.Node
+	)
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value == "sops" {
+			err = doc.Content[i+1].Decode(&meta)
+			if err != nil {
+				return nil, fmt.Errorf("reader: sops: invalid metadata; %w", err)
+			}
+			continue
+		}
+		tree = append(tree, doc.Content[i], doc.Content[i+1])
+	}
+	if meta.MAC == "" {
+		return nil, errors.New("reader: sops: metadata not found")
+	}
+	if meta.UnencryptedCommentRegex != "" || meta.EncryptedCommentRegex != "" {
+		return nil, errors.New("reader: sops: comment regex is not supported")
+	}
+
+	keys := meta.masterKeys()
+	if len(meta.KeyGroups) > 1 || meta.ShamirThreshold > 1 {
+		return nil, errors.New("reader: sops: shamir key groups are not supported")
+	}
+	if len(meta.KeyGroups) == 1 {
+		keys = meta.KeyGroups[0].masterKeys()
+	}
+	key, err := dataKey(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	d := sopsDecoder{
+		meta: &meta,
+		key:  key,
+		hash: sha512.New(),
+		out:  make(map[string]string),
+	}
+	if meta.MACOnlyEncrypted {
+		d.hash.Write(sopsMACOnlyEncryptedInit)
+	}
+	for i := 0; i < len(tree); i += 2 {
+		k := tree[i].Value
+		err = d.walk(tree[i+1], []string{k}, k)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lastModified, err := time.Parse(time.RFC3339, meta.LastModified)
+	if err != nil {
+		return nil, fmt.Errorf("reader: sops: invalid lastmodified; %w", err)
+	}
+	mac, _, err := sopsDecrypt(meta.MAC, key, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("reader: sops: can not decrypt mac; %w", err)
+	}
+	if string(mac) != fmt.Sprintf("%X", d.hash.Sum(nil)) {
+		return nil, errors.New("reader: sops: mac mismatch")
+	}
+	return d.out, nil
+}
+
+type sopsDecoder struct {
+	meta *sopsMetadata
+	key  []byte
+	hash hash.Hash
+	out  map[string]string
+}
+
+// walk walks node in document order, path is sops path (without sequence index)
+// used as additional data, name is output key
+func (d *sopsDecoder) walk(n *yaml.Node, path []string, name string) error {
+	switch n.Kind {
+	case yaml.AliasNode:
+		return d.walk(n.Alias, path, name)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k := n.Content[i].Value
+			err := d.walk(n.Content[i+1], append(path[:len(path):len(path)], k), name+"."+k)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.SequenceNode:
+		for i, x := range n.Content {
+			err := d.walk(x, path, name+"."+strconv.Itoa(i))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.ScalarNode:
+	default:
+		return fmt.Errorf("reader: sops: unsupported node at %s", name)
+	}
+
+	if n.Tag == "!!null" {
+		d.out[name] = ""
+		return nil
+	}
+
+	encrypted := d.shouldBeEncrypted(path)
+	var (
+		value string
+		mac   []byte
+	)
+	if encrypted {
+		p, typ, err := sopsDecrypt(n.Value, d.key, strings.Join(path, ":")+":")
+		if err != nil {
+			return fmt.Errorf("reader: sops: can not decrypt %s; %w", name, err)
+		}
+		value, mac = string(p), p
+		switch typ {
+		case "float":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return err
+			}
+			mac = []byte(strconv.FormatFloat(f, 'f', -1, 64))
+		case "bool":
+			v, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			value, mac = strconv.FormatBool(v), []byte(sopsBool(v))
+		}
+	} else {
+		value = n.Value
+		var v interface{}
+		n.Decode(&v)
+		switch v := v.(type) {
+		case float64:
+			mac = []byte(strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			value, mac = strconv.FormatBool(v), []byte(sopsBool(v))
+		case int:
+			mac = []byte(strconv.Itoa(v))
+		default:
+			mac = []byte(n.Value)
+		}
+	}
+	if encrypted || !d.meta.MACOnlyEncrypted {
+		d.hash.Write(mac)
+	}
+	d.out[name] = value
+	return nil
+}
+
+func sopsBool(v bool) string {
+	if v {
+		return "True"
+	}
+	return "False"
+}
+
+func (d *sopsDecoder) shouldBeEncrypted(path []string) bool {
+	m := d.meta
+	encrypted := true
+	if m.UnencryptedSuffix != "" {
+		for _, p := range path {
+			if strings.HasSuffix(p, m.UnencryptedSuffix) {
+				encrypted = false
+				break
+			}
+		}
+	}
+	if m.EncryptedSuffix != "" {
+		encrypted = false
+		for _, p := range path {
+			if strings.HasSuffix(p, m.EncryptedSuffix) {
+				encrypted = true
+				break
+			}
+		}
+	}
+	if m.UnencryptedRegex != "" {
+		for _, p := range path {
+			if ok, _ := regexp.MatchString(m.UnencryptedRegex, p); ok {
+				encrypted = false
+				break
+			}
+		}
+	}
+	if m.EncryptedRegex != "" {
+		encrypted = false
+		for _, p := range path {
+			if ok, _ := regexp.MatchString(m.EncryptedRegex, p); ok {
+				encrypted = true
+				break
+			}
+		}
+	}
+	return encrypted
+}
+
+var sopsValuePattern = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.+),iv:(.+),tag:(.+),type:(.+)\]`)
+
+// sopsDecrypt decrypts sops value, returns plaintext and its type
+func sopsDecrypt(value string, key []byte, additionalData string) ([]byte, string, error) {
+	if value == "" {
+		return []byte{}, "str", nil
+	}
+	m := sopsValuePattern.FindStringSubmatch(value)
+	if m == nil {
+		return nil, "", errors.New("value is not encrypted")
+	}
+
+	var parts [3][]byte
+	for i := range parts {
+		b, err := base64.StdEncoding.DecodeString(m[i+1])
+		if err != nil {
+			return nil, "", err
+		}
+		parts[i] = b
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, "", err
+	}
+	aead, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, "", err
+	}
+	p, err := aead.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return nil, "", err
+	}
+	return p, m[4], nil
+}
+
+// NewSOPSFile creates new reloadable reader from sops encrypted yaml or json file
+func NewSOPSFile(filename string, dataKey SOPSDataKey) *SOPS {
+	var rd SOPS
+	rd.decode = func(r io.Reader) (map[string]string, error) {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return DecodeSOPS(b, dataKey)
+	}
+	rd.initFile(filename)
+	return &rd
+}
+
+// SOPS reads config from sops encrypted file
+type SOPS struct {
+	file
+}
+
+// Read reads a config
+func (r *SOPS) Read(name string) ([]byte, error) {
+	return r.read(name)
+}
+
+// Keys returns all names
+func (r *SOPS) Keys() ([]string, error) {
+	return r.keys(), nil
+}
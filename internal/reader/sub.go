@@ -0,0 +1,96 @@
+package reader
+
+import "strings"
+
+type source interface {
+	Read(name string) ([]byte, error)
+}
+
+// NewSub creates new reader that reads names under prefix from r,
+// prefix and name are joined with separators that match r's naming
+func NewSub(r source, prefix string) *Sub {
+	if s, ok := r.(*Sub); ok {
+		return &Sub{r: s.r, path: append(s.path[:len(s.path):len(s.path)], prefix), seps: s.seps}
+	}
+	return &Sub{r: r, path: []string{prefix}, seps: separators(r)}
+}
+
+// separators returns separators in the order to try
+func separators(r interface{}) []string {
+	if c, ok := r.(*Cached); ok {
+		r = c.r
+	}
+	switch r.(type) {
+	case *YAML, *JSON, *SOPS:
+		return []string{"."}
+	case *Dir, *DirSnapshot, *FS, *Consul, *Etcd:
+		return []string{"/", "_"}
+	case *Env, *DotEnv:
+		return []string{"_"}
+	case *Flag, *Args:
+		return []string{"-"}
+	}
+	return []string{".", "/", "_"}
+}
+
+// Sub reads config under prefix
+type Sub struct {
+	r    source
+	path []string
+	seps []string
+}
+
+// Separators returns separators that join prefix and name, in the order to try
+func (r *Sub) Separators() []string {
+	return r.seps
+}
+
+// Read reads a config
+func (r *Sub) Read(name string) ([]byte, error) {
+	var err error
+	for _, sep := range r.seps {
+		var b []byte
+		b, err = r.r.Read(strings.Join(r.path, sep) + sep + name)
+		if err == nil {
+			return b, nil
+		}
+	}
+	return nil, err
+}
+
+// Keys returns names under prefix with prefix removed
+func (r *Sub) Keys() ([]string, error) {
+	l, ok := r.r.(interface{ Keys() ([]string, error) })
+	if !ok {
+		return nil, nil
+	}
+	keys, err := l.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	var xs []string
+	for _, sep := range r.seps {
+		prefix := strings.Join(r.path, sep) + sep
+		switch r.r.(type) {
+		case *Env, *DotEnv:
+			prefix = strings.ToLower(prefix)
+		case *Flag, *Args:
+			prefix = FlagName(prefix)
+		}
+		for _, k := range keys {
+			if strings.HasPrefix(k, prefix) {
+				xs = append(xs, k[len(prefix):])
+			}
+		}
+	}
+	return xs, nil
+}
+
+// Reload reloads underlying reader
+func (r *Sub) Reload() error {
+	if rd, ok := r.r.(interface{ Reload() error }); ok {
+		return rd.Reload()
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultOptions configures vault kv reader
+type VaultOptions struct {
+	// Address is vault server address, e.g. https://vault:8200
+	Address string
+
+	// Token authenticates requests, AppRole is used when Token is empty
+	Token string
+
+	// RoleID and SecretID authenticate with AppRole
+	RoleID   string
+	SecretID string
+
+	// AppRoleMount is the mount of approle auth method, default is "approle"
+	AppRoleMount string
+
+	// Namespace is vault enterprise namespace
+	Namespace string
+
+	// Mount is the mount of kv secrets engine, default is "secret"
+	Mount string
+
+	// Path is the secret to read, its fields are config names.
+	// Names with "/" read from secrets under Path, e.g. "db/password"
+	// reads field password of secret <Path>/db
+	Path string
+
+	// Version is kv secrets engine version, 1 or 2, default is 2
+	Version int
+
+	// TTL is how long secrets are cached when vault returns no lease,
+	// zero caches until reload
+	TTL time.Duration
+
+	// Client is http client for requests, default has 10 seconds timeout
+	Client *http.Client
+}
+
+// NewVault creates new vault kv reader
+func NewVault(opt VaultOptions) *Vault {
+	if opt.AppRoleMount == "" {
+		opt.AppRoleMount = "approle"
+	}
+	if opt.Mount == "" {
+		opt.Mount = "secret"
+	}
+	if opt.Version == 0 {
+		opt.Version = 2
+	}
+	if opt.Client == nil {
+		opt.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	opt.Address = strings.TrimSuffix(opt.Address, "/")
+
+	r := Vault{opt: opt, token: opt.Token}
+	r.remote = remote{fetch: r.fetch, ttl: opt.TTL, now: time.Now}
+	return &r
+}
+
+// Vault reads config from vault kv secrets engine
+type Vault struct {
+	remote
+	opt VaultOptions
+
+	authMu   sync.Mutex
+	token    string
+	tokenExp time.Time
+}
+
+// Read reads a config
+func (r *Vault) Read(name string) ([]byte, error) {
+	b, err := r.read("", name)
+	if err == nil || !strings.Contains(name, "/") {
+		return b, err
+	}
+	i := strings.LastIndex(name, "/")
+	return r.read(name[:i], name[i+1:])
+}
+
+// Keys returns all fields of secret at Path
+func (r *Vault) Keys() ([]string, error) {
+	return r.keys("")
+}
+
+type vaultError struct {
+	Errors []string `json:"errors"`
+}
+
+func (r *Vault) do(method, p string, body interface{}, token string) (*http.Response, error) {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, r.opt.Address+"/v1/"+p, rd)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if r.opt.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", r.opt.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return r.opt.Client.Do(req)
+}
+
+func vaultResponseError(resp *http.Response) error {
+	var e vaultError
+	json.NewDecoder(resp.Body).Decode(&e)
+	if len(e.Errors) > 0 {
+		return fmt.Errorf("reader: vault %s; %s", resp.Status, strings.Join(e.Errors, ", "))
+	}
+	return fmt.Errorf("reader: vault %s", resp.Status)
+}
+
+// auth returns token, login with approle when token is empty or expired
+func (r *Vault) auth(renew bool) (string, error) {
+	r.authMu.Lock()
+	defer r.authMu.Unlock()
+
+	if r.opt.RoleID == "" {
+		return r.token, nil
+	}
+	if !renew && r.token != "" && (r.tokenExp.IsZero() || time.Now().Before(r.tokenExp)) {
+		return r.token, nil
+	}
+
+	resp, err := r.do(http.MethodPost, "auth/"+r.opt.AppRoleMount+"/login", map[string]string{
+		"role_id":   r.opt.RoleID,
+		"secret_id": r.opt.SecretID,
+	}, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", vaultResponseError(resp)
+	}
+
+	var res struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int64  `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return "", err
+	}
+	r.token = res.Auth.ClientToken
+	r.tokenExp = time.Time{}
+	if d := time.Duration(res.Auth.LeaseDuration) * time.Second; d > 0 {
+		// renew before token expires
+		r.tokenExp = time.Now().Add(d - d/10)
+	}
+	return r.token, nil
+}
+
+func (r *Vault) secretPath(key string) string {
+	p := path.Join(r.opt.Path, key)
+	if r.opt.Version == 2 {
+		return path.Join(r.opt.Mount, "data", p)
+	}
+	return path.Join(r.opt.Mount, p)
+}
+
+func (r *Vault) fetch(key string) (map[string]string, time.Duration, error) {
+	token, err := r.auth(false)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := r.do(http.MethodGet, r.secretPath(key), nil, token)
+	if err == nil && resp.StatusCode == http.StatusForbidden && r.opt.RoleID != "" {
+		// token may be revoked before its lease ends
+		resp.Body.Close()
+		token, err = r.auth(true)
+		if err != nil {
+			return nil, 0, err
+		}
+		resp, err = r.do(http.MethodGet, r.secretPath(key), nil, token)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, 0, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, vaultResponseError(resp)
+	}
+
+	var res struct {
+		LeaseDuration int64           `json:"lease_duration"`
+		Data          json.RawMessage `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, 0, err
+	}
+	data := res.Data
+	if r.opt.Version == 2 {
+		var v2 struct {
+			Data json.RawMessage `json:"data"`
+		}
+		err = json.Unmarshal(data, &v2)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(v2.Data) == 0 || string(v2.Data) == "null" {
+			// deleted secret version
+			return nil, 0, errNotFound
+		}
+		data = v2.Data
+	}
+
+	d, err := DecodeJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, err
+	}
+	return d, time.Duration(res.LeaseDuration) * time.Second, nil
+}
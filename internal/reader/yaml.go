@@ -1,7 +1,9 @@
 package reader
 
 import (
+	"errors"
 	"io"
+	"strconv"
 
 	"gopkg.in/yaml.v3"
 )
@@ -9,22 +11,130 @@ import (
 // NewYAML creates new yaml reader
 func NewYAML(r io.Reader) *YAML {
 	var rd YAML
-	if r != nil {
-		yaml.NewDecoder(r).Decode(&rd.d)
-	}
+	rd.decode = DecodeYAML
+	rd.init(r)
+	return &rd
+}
+
+// NewYAMLFile creates new reloadable yaml reader from file
+func NewYAMLFile(filename string) *YAML {
+	var rd YAML
+	rd.decode = DecodeYAML
+	rd.initFile(filename)
 	return &rd
 }
 
 // YAML reads config from yaml file
 type YAML struct {
-	d map[string]string
+	file
 }
 
 // Read reads a config
 func (r *YAML) Read(name string) ([]byte, error) {
-	p, ok := r.d[name]
-	if !ok {
-		return nil, errNotFound
+	return r.read(name)
+}
+
+// Keys returns all names
+func (r *YAML) Keys() ([]string, error) {
+	return r.keys(), nil
+}
+
+// DecodeYAML decodes yaml document into string map,
+// nested mappings and sequences are flatten with "." (redis.addr, hosts.0).
+// sops encrypted document is decrypted with DefaultSOPSDataKey
+func DecodeYAML(r io.Reader) (map[string]string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if IsSOPS(b) {
+		return DecodeSOPS(b, DefaultSOPSDataKey)
+	}
+
+	var root yaml.Node
+	err = yaml.Unmarshal(b, &root)
+	if err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return nil, nil
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return nil, errors.New("reader: yaml document is not a mapping")
+	}
+
+	d := make(map[string]string)
+	flattenYAML(d, "", doc)
+	return d, nil
+}
+
+func flattenYAML(d map[string]string, name string, n *yaml.Node) {
+	switch n.Kind {
+	case yaml.AliasNode:
+		flattenYAML(d, name, n.Alias)
+	case yaml.MappingNode:
+		keys, values := yamlPairs(n)
+		for _, k := range keys {
+			flattenYAML(d, joinName(name, k), values[k])
+		}
+	case yaml.SequenceNode:
+		for i, x := range n.Content {
+			flattenYAML(d, joinName(name, strconv.Itoa(i)), x)
+		}
+	case yaml.ScalarNode:
+		var s string
+		n.Decode(&s)
+		d[name] = s
+	}
+}
+
+// yamlPairs returns keys and values of mapping n with merge keys applied,
+// explicit keys override merged keys, and earlier mappings of <<: [*a, *b] override later ones
+func yamlPairs(n *yaml.Node) (keys []string, values map[string]*yaml.Node) {
+	values = make(map[string]*yaml.Node)
+	add := func(k string, v *yaml.Node) {
+		if _, ok := values[k]; !ok {
+			keys = append(keys, k)
+			values[k] = v
+		}
+	}
+
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if k := n.Content[i]; k.ShortTag() == "!!merge" {
+			merges = append(merges, n.Content[i+1])
+			continue
+		}
+		add(n.Content[i].Value, n.Content[i+1])
+	}
+	for _, m := range merges {
+		if m.Kind == yaml.AliasNode {
+			m = m.Alias
+		}
+		xs := []*yaml.Node{m}
+		if m.Kind == yaml.SequenceNode {
+			xs = m.Content
+		}
+		for _, x := range xs {
+			if x.Kind == yaml.AliasNode {
+				x = x.Alias
+			}
+			if x.Kind != yaml.MappingNode {
+				continue
+			}
+			ks, vs := yamlPairs(x)
+			for _, k := range ks {
+				add(k, vs[k])
+			}
+		}
+	}
+	return keys, values
+}
+
+func joinName(prefix, name string) string {
+	if prefix == "" {
+		return name
 	}
-	return []byte(p), nil
+	return prefix + "." + name
 }
@@ -0,0 +1,42 @@
+package configfile
+
+import (
+	"sort"
+	"strings"
+)
+
+type keyLister interface {
+	Keys() ([]string, error)
+}
+
+// Keys returns sorted names from all sources in the chain,
+// names from env are in lower case, sources that can not list names are skipped
+func (r *Reader) Keys() []string {
+	return r.KeysWithPrefix("")
+}
+
+// KeysWithPrefix returns sorted names that start with prefix, see Keys
+func (r *Reader) KeysWithPrefix(prefix string) []string {
+	seen := make(map[string]bool)
+	var xs []string
+	add := func(l keyLister) {
+		keys, _ := l.Keys()
+		for _, k := range keys {
+			if seen[k] || !strings.HasPrefix(k, prefix) {
+				continue
+			}
+			seen[k] = true
+			xs = append(xs, k)
+		}
+	}
+	for p := r; p != nil; p = p.fallback {
+		if o := p.overlay.Load(); o != nil {
+			add(o)
+		}
+		if l, ok := p.r.(keyLister); ok {
+			add(l)
+		}
+	}
+	sort.Strings(xs)
+	return xs
+}
@@ -0,0 +1,44 @@
+package configfile_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestKeys(t *testing.T) {
+	data := []string{"data1", "data2", "data3", "data4", "data5", "data6", "data7"}
+
+	assert.Equal(t, data, configfile.NewYAMLReader("testdata/config.yaml").KeysWithPrefix("data"))
+	assert.Equal(t, data, configfile.NewJSONReader("testdata/config.json").KeysWithPrefix("data"))
+	assert.Equal(t, data, configfile.NewDotEnvReader("testdata/config.env").KeysWithPrefix("data"))
+	assert.Equal(t, data, configfile.NewDirReader("testdata").KeysWithPrefix("data"))
+	assert.Equal(t, data, configfile.NewDirSnapshotReader("testdata").KeysWithPrefix("data"))
+	assert.Subset(t, configfile.NewEnvReader().KeysWithPrefix("data"), data)
+	assert.Equal(t, []string{"production/workers"}, configfile.NewDirReader("testdata/profile").KeysWithPrefix("production/"))
+
+	c := configfile.NewArgsReader([]string{"--extra=1"}).
+		Fallback(configfile.NewYAMLReader("testdata/config.yaml").
+			Fallback(configfile.NewEnvReader()))
+	keys := c.Keys()
+	assert.Contains(t, keys, "extra")
+	assert.Contains(t, keys, "empty")
+	assert.Contains(t, keys, "onlyenv")
+	seen := map[string]bool{}
+	for _, k := range keys {
+		assert.False(t, seen[k], "duplicated key %s", k)
+		seen[k] = true
+	}
+
+	assert.Nil(t, configfile.NewDirReader("notexists").Keys())
+}
+
+func TestKeysConfigMap(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigMap(t, dir, "..v1", map[string]string{"host": "a", "port": "1"})
+
+	assert.Equal(t, []string{"host", "port"}, configfile.NewDirReader(dir).Keys())
+	assert.Equal(t, []string{"host", "port"}, configfile.NewDirSnapshotReader(dir).Keys())
+}
@@ -0,0 +1,15 @@
+package configfile
+
+import "github.com/acoshift/configfile/internal/reader"
+
+// KubernetesOptions configures NewKubernetesReader
+type KubernetesOptions = reader.KubernetesOptions
+
+// NewKubernetesReader creates new reader from keys of a ConfigMap or Secret
+// read from kubernetes api, without waiting for kubelet to sync a mounted volume.
+// Values are normalized like NewDirReader.
+// In cluster, pod service account needs get, list and watch permission on the object.
+// Call Reload to load the object again, or WatchRemote to receive changes from an informer
+func NewKubernetesReader(opt KubernetesOptions) *Reader {
+	return &Reader{r: reader.NewKubernetes(opt), norm: NormalizeTrimNewline}
+}
@@ -0,0 +1,62 @@
+//go:build integration
+
+package configfile_test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/acoshift/configfile"
+)
+
+// kubernetesClient returns client of the cluster in KUBECONFIG, e.g. from
+//
+//	kind create cluster
+//	KUBECONFIG=$HOME/.kube/config go test -tags integration -run Kubernetes ./...
+func kubernetesClient(t *testing.T) kubernetes.Interface {
+	kubeconfig := os.Getenv("KUBECONFIG")
+	if kubeconfig == "" {
+		t.Skip("KUBECONFIG not set")
+	}
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	require.NoError(t, err)
+	client, err := kubernetes.NewForConfig(config)
+	require.NoError(t, err)
+	return client
+}
+
+func TestKubernetesReaderIntegration(t *testing.T) {
+	client := kubernetesClient(t)
+	configMaps := client.CoreV1().ConfigMaps("default")
+	ctx := context.Background()
+	name := "configfile-test-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	_, err := configMaps.Create(ctx, configMap(name, map[string]string{"port": "8080"}), metav1.CreateOptions{})
+	require.NoError(t, err)
+	t.Cleanup(func() { configMaps.Delete(ctx, name, metav1.DeleteOptions{}) })
+
+	c := configfile.NewKubernetesReader(configfile.KubernetesOptions{
+		Namespace: "default",
+		Name:      name,
+	}).Debounce(0)
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.Equal(t, []string{"port"}, c.Keys())
+
+	port := configfile.Watch(c, "port", 0)
+	stop := c.WatchRemote()
+	defer stop()
+
+	// informer lists first, updates before its watch starts are not missed
+	_, err = configMaps.Update(ctx, configMap(name, map[string]string{"port": "8081"}), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return port.Load() == 8081 }, 10*time.Second, 10*time.Millisecond)
+}
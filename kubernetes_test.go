@@ -0,0 +1,193 @@
+package configfile_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/acoshift/configfile"
+)
+
+// newFakeKubernetes returns fake clientset with objects,
+// watching is closed when the first watch is started, fake watches miss earlier changes
+func newFakeKubernetes(objects ...runtime.Object) (client *fake.Clientset, watching <-chan struct{}) {
+	client = fake.NewSimpleClientset(objects...)
+	ch := make(chan struct{})
+	var once sync.Once
+	client.PrependWatchReactor("*", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		w, err := client.Tracker().Watch(action.GetResource(), action.GetNamespace())
+		once.Do(func() { close(ch) })
+		return true, w, err
+	})
+	return client, ch
+}
+
+func configMap(name string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Data:       data,
+	}
+}
+
+func TestKubernetesReader(t *testing.T) {
+	cm := configMap("myapp", map[string]string{"port": "8080", "debug": "true\n"})
+	cm.BinaryData = map[string][]byte{"cert": []byte("pem")}
+	client, _ := newFakeKubernetes(cm, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("pass")},
+	})
+
+	c := configfile.NewKubernetesReader(configfile.KubernetesOptions{Client: client, Name: "myapp"})
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.True(t, c.Bool("debug"))
+	assert.Equal(t, "true", c.String("debug"))
+	assert.Equal(t, "pem", c.String("cert"))
+	assert.Equal(t, []string{"cert", "debug", "port"}, c.Keys())
+
+	s := configfile.NewKubernetesReader(configfile.KubernetesOptions{Client: client, Namespace: "default", Name: "myapp", Secret: true})
+	assert.Equal(t, "pass", s.String("password"))
+
+	_, err := client.CoreV1().ConfigMaps("default").Update(context.Background(), configMap("myapp", map[string]string{"port": "8081"}), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 8080, c.Int("port"))
+	require.NoError(t, c.Reload())
+	assert.Equal(t, 8081, c.Int("port"))
+	assert.Equal(t, []string{"port"}, c.Keys())
+
+	notFound := configfile.NewKubernetesReader(configfile.KubernetesOptions{Client: client, Name: "notfound"})
+	assert.Equal(t, "", notFound.String("port"))
+	assert.NoError(t, notFound.Reload())
+
+	forbidden, _ := newFakeKubernetes(cm)
+	forbidden.PrependReactor("get", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "myapp", errors.New("denied"))
+	})
+	c = configfile.NewKubernetesReader(configfile.KubernetesOptions{Client: forbidden, Name: "myapp"}).
+		Fallback(configfile.NewMapReader(map[string]string{"port": "1"}))
+	assert.Equal(t, 1, c.Int("port"))
+	assert.Error(t, c.Reload())
+}
+
+func TestKubernetesReaderWatch(t *testing.T) {
+	client, watching := newFakeKubernetes()
+	configMaps := client.CoreV1().ConfigMaps("default")
+	ctx := context.Background()
+
+	c := configfile.NewKubernetesReader(configfile.KubernetesOptions{Client: client, Name: "myapp"}).Debounce(0)
+	port := configfile.Watch(c, "port", 0)
+
+	stop := c.WatchRemote()
+	defer stop()
+
+	select {
+	case <-watching:
+	case <-time.After(2 * time.Second):
+		t.Fatal("informer not started")
+	}
+
+	// key added after start
+	_, err := configMaps.Create(ctx, configMap("myapp", map[string]string{"port": "8080"}), metav1.CreateOptions{})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return port.Load() == 8080 }, 2*time.Second, 10*time.Millisecond)
+
+	_, err = configMaps.Create(ctx, configMap("other", map[string]string{"port": "9000"}), metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = configMaps.Update(ctx, configMap("myapp", map[string]string{"port": "8081"}), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return port.Load() == 8081 }, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, configMaps.Delete(ctx, "myapp", metav1.DeleteOptions{}))
+	assert.Eventually(t, func() bool { return port.Load() == 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.Empty(t, c.Keys())
+}
+
+func TestKubernetesReaderWatchError(t *testing.T) {
+	client, _ := newFakeKubernetes(configMap("myapp", map[string]string{"port": "8080"}))
+	client.PrependWatchReactor("configmaps", func(k8stesting.Action) (bool, watch.Interface, error) {
+		return true, nil, errors.New("watch failed")
+	})
+
+	c := configfile.NewKubernetesReader(configfile.KubernetesOptions{Client: client, Name: "myapp"})
+	errs := make(chan error, 10)
+	c.OnWatchError(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+
+	stop := c.WatchRemote()
+	defer stop()
+
+	select {
+	case err := <-errs:
+		assert.ErrorContains(t, err, "watch failed")
+	case <-time.After(2 * time.Second):
+		t.Fatal("error not reported")
+	}
+	assert.Equal(t, 8080, c.Int("port"), "must serve last values")
+}
+
+func TestKubernetesReaderKubeconfig(t *testing.T) {
+	paths := make(chan string, 1)
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case paths <- r.URL.Path:
+		default:
+		}
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(kubeconfig, []byte(`
+apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: `+srv.URL+`
+contexts:
+- name: test
+  context:
+    cluster: test
+    namespace: myns
+current-context: test
+`), 0644))
+
+	start := time.Now()
+	c := configfile.NewKubernetesReader(configfile.KubernetesOptions{
+		Kubeconfig: kubeconfig,
+		Name:       "myapp",
+		Timeout:    50 * time.Millisecond,
+	}).Fallback(configfile.NewMapReader(map[string]string{"port": "8080"}))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.Equal(t, "/api/v1/namespaces/myns/configmaps/myapp", <-paths, "must use kubeconfig server and namespace")
+
+	c = configfile.NewKubernetesReader(configfile.KubernetesOptions{
+		Kubeconfig: filepath.Join(t.TempDir(), "notfound"),
+		Name:       "myapp",
+	}).Fallback(configfile.NewMapReader(map[string]string{"port": "8080"}))
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.Error(t, c.Reload())
+}
@@ -0,0 +1,41 @@
+package configfile
+
+import "bytes"
+
+// Normalization is how values from a source are normalized before parsed
+type Normalization int
+
+// Normalizations
+const (
+	// NormalizeRaw returns values as is
+	NormalizeRaw Normalization = iota
+
+	// NormalizeTrimNewline removes a trailing "\n" or "\r\n",
+	// files written with echo or most editors end with one.
+	// It is the default for NewDirReader, NewDirSnapshotReader, NewFSReader and NewKubernetesReader
+	NormalizeTrimNewline
+
+	// NormalizeTrimSpace removes leading and trailing white space
+	NormalizeTrimSpace
+)
+
+func (n Normalization) apply(b []byte) []byte {
+	switch n {
+	case NormalizeTrimNewline:
+		if !bytes.HasSuffix(b, []byte("\n")) {
+			return b
+		}
+		return bytes.TrimSuffix(b[:len(b)-1], []byte("\r"))
+	case NormalizeTrimSpace:
+		return bytes.TrimSpace(b)
+	}
+	return b
+}
+
+// Normalize sets normalization for values from this reader's source,
+// fallback readers keep their own normalization.
+// Use NormalizeRaw for dir readers that hold binary files
+func (r *Reader) Normalize(n Normalization) *Reader {
+	r.norm = n
+	return r
+}
@@ -0,0 +1,56 @@
+package configfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestNormalize(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"port":    "8080\n",
+		"debug":   "true\r\n",
+		"timeout": " 5s \n",
+		"cert":    "line1\nline2\n\n",
+	}
+	for k, v := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, k), []byte(v), 0644))
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		c := configfile.NewDirReader(dir)
+		assert.Equal(t, 8080, c.Int("port"))
+		assert.True(t, c.Bool("debug"))
+		assert.Equal(t, time.Duration(0), c.Duration("timeout"))
+		assert.Equal(t, "line1\nline2\n", c.String("cert"))
+		assert.Equal(t, []byte("hello"), configfile.NewDirReader("testdata").Base64("data6"))
+		assert.Equal(t, "aGVsbG8=", configfile.NewDirSnapshotReader("testdata").String("data6"))
+		assert.Equal(t, "aGVsbG8=", configfile.NewFSReader(os.DirFS("testdata")).String("data6"))
+	})
+
+	t.Run("TrimSpace", func(t *testing.T) {
+		c := configfile.NewDirReader(dir).Normalize(configfile.NormalizeTrimSpace)
+		assert.Equal(t, 5*time.Second, c.Duration("timeout"))
+		assert.Equal(t, "line1\nline2", c.String("cert"))
+	})
+
+	t.Run("Raw", func(t *testing.T) {
+		c := configfile.NewDirReader(dir).Normalize(configfile.NormalizeRaw)
+		assert.Equal(t, 0, c.Int("port"))
+		assert.Equal(t, "true\r\n", c.String("debug"))
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		c := configfile.NewYAMLReaderFromReader(strings.NewReader("name: \" a \"\n")).
+			Fallback(configfile.NewDirReader(dir))
+		assert.Equal(t, " a ", c.String("name"))
+		assert.Equal(t, 8080, c.Int("port"))
+	})
+}
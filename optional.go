@@ -0,0 +1,35 @@
+package configfile
+
+// Optional is a config value that may not be set
+type Optional[T any] struct {
+	Value T
+	OK    bool
+}
+
+// Get returns value and whether it is set
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.OK
+}
+
+// Or returns value if set, otherwise def
+func (o Optional[T]) Or(def T) T {
+	if !o.OK {
+		return def
+	}
+	return o.Value
+}
+
+// Lookup reads config then parses as T, supports the same types as Get.
+// OK is false when config not found or data can not parse to T,
+// so a value set to zero can be told apart from unset
+func Lookup[T any](r *Reader, name string) Optional[T] {
+	b, err := r.read(name)
+	if err != nil {
+		return Optional[T]{}
+	}
+	v, err := parseValue[T](r, b)
+	if err != nil {
+		return Optional[T]{}
+	}
+	return Optional[T]{Value: v, OK: true}
+}
@@ -0,0 +1,40 @@
+package configfile_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestLookup(t *testing.T) {
+	c := configfile.NewMapReader(map[string]string{
+		"retries": "0",
+		"timeout": "5s",
+		"invalid": "abc",
+		"empty":   "",
+	})
+
+	v, ok := configfile.Lookup[int](c, "retries").Get()
+	assert.True(t, ok)
+	assert.Equal(t, 0, v)
+	assert.Equal(t, 0, configfile.Lookup[int](c, "retries").Or(3))
+
+	v, ok = configfile.Lookup[int](c, "notfound").Get()
+	assert.False(t, ok)
+	assert.Equal(t, 0, v)
+	assert.Equal(t, 3, configfile.Lookup[int](c, "notfound").Or(3))
+
+	assert.Equal(t, configfile.Optional[time.Duration]{Value: 5 * time.Second, OK: true}, configfile.Lookup[time.Duration](c, "timeout"))
+	assert.False(t, configfile.Lookup[int](c, "invalid").OK)
+	assert.False(t, configfile.Lookup[int](c, "empty").OK)
+	assert.Equal(t, configfile.Optional[string]{OK: true}, configfile.Lookup[string](c, "empty"))
+
+	c.Empty(configfile.EmptyAsZero)
+	assert.Equal(t, configfile.Optional[int]{OK: true}, configfile.Lookup[int](c, "empty"))
+
+	c.Empty(configfile.EmptyAsUnset)
+	assert.False(t, configfile.Lookup[string](c, "empty").OK)
+}
@@ -0,0 +1,120 @@
+package configfile
+
+import "github.com/acoshift/configfile/internal/reader"
+
+// override is a value set by Override, identified by id so it can be restored in any order
+type override struct {
+	id    uint64
+	value string
+}
+
+// Override sets value of name on top of all sources until restore is called,
+// subscribers registered with OnChange get notified.
+// Overrides of the same name stack, restore removes only its own value,
+// so overrides can be restored in any order.
+// Readers from Sub see overrides with prefixed name.
+// It is intended for tests, see configtest.Override and With for parallel tests
+func (r *Reader) Override(name, value string) (restore func()) {
+	r.mu.Lock()
+	o := r.overlay.Load()
+	if o == nil {
+		o = reader.NewMap(nil)
+		r.overlay.Store(o)
+	}
+	if r.overrides == nil {
+		r.overrides = make(map[string][]override)
+	}
+	r.overrideID++
+	id := r.overrideID
+	r.overrides[name] = append(r.overrides[name], override{id, value})
+	o.Set(name, value)
+	r.mu.Unlock()
+	r.changed()
+
+	return func() {
+		r.mu.Lock()
+		xs := r.overrides[name]
+		for i, x := range xs {
+			if x.id == id {
+				xs = append(xs[:i:i], xs[i+1:]...)
+				break
+			}
+		}
+		if len(xs) == 0 {
+			delete(r.overrides, name)
+			o.Delete(name)
+		} else {
+			r.overrides[name] = xs
+			o.Set(name, xs[len(xs)-1].value)
+		}
+		r.mu.Unlock()
+		r.changed()
+	}
+}
+
+// overridden returns value of name set by Override on r,
+// or on parent of reader created from Sub
+func (r *Reader) overridden(name string) ([]byte, bool) {
+	if o := r.overlay.Load(); o != nil {
+		if p, ok := o.Lookup(name); ok {
+			return []byte(p), true
+		}
+	}
+	if r.parent == nil {
+		return nil, false
+	}
+	seps := []string{"."}
+	if s, ok := r.r.(*reader.Sub); ok {
+		seps = s.Separators()
+	}
+	for _, sep := range seps {
+		if p, ok := r.parent.overridden(r.prefix + sep + name); ok {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// With returns new reader that reads values from m then r, r is unchanged.
+// Use it to give each parallel test its own values
+func (r *Reader) With(m map[string]string) *Reader {
+	return &Reader{r: reader.NewMap(m), fallback: r, empty: r.empty}
+}
+
+// changed notifies watchers that values may changed,
+// readers from Sub share sources with their parent, so the whole tree is notified
+func (r *Reader) changed() {
+	root := r
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.notify()
+}
+
+// notify triggers watcher of r and readers from Sub of r
+func (r *Reader) notify() {
+	r.mu.Lock()
+	w := r.w
+	children := r.children
+	r.mu.Unlock()
+	if w != nil {
+		w.trigger()
+	}
+	for _, c := range children {
+		c.notify()
+	}
+}
+
+// addChild adds reader from Sub of r to be notified, reports whether c is new
+func (r *Reader) addChild(c *Reader) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, x := range r.children {
+		if x == c {
+			return false
+		}
+	}
+	r.children = append(r.children, c)
+	return true
+}
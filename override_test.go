@@ -0,0 +1,124 @@
+package configfile_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestMapReader(t *testing.T) {
+	m := map[string]string{"data1": "true", "data3": "9"}
+	c := configfile.NewMapReader(m)
+	m["data3"] = "10"
+
+	assert.True(t, c.Bool("data1"))
+	assert.Equal(t, 9, c.Int("data3"))
+	assert.Equal(t, "", c.String("notfound"))
+	assert.Equal(t, []string{"data1", "data3"}, c.Keys())
+}
+
+func TestOverride(t *testing.T) {
+	c := configfile.NewMapReader(map[string]string{"data1": "true"}).
+		Fallback(configfile.NewYAMLReader("testdata/config.yaml"))
+
+	restore1 := c.Override("data1", "false")
+	restore2 := c.Override("data3", "10")
+	restore3 := c.Override("extra", "1")
+	assert.False(t, c.Bool("data1"))
+	assert.Equal(t, 10, c.Int("data3"))
+	assert.Contains(t, c.Keys(), "extra")
+
+	restore4 := c.Override("data3", "11")
+	assert.Equal(t, 11, c.Int("data3"))
+	restore4()
+	assert.Equal(t, 10, c.Int("data3"))
+
+	restore1()
+	restore2()
+	restore3()
+	assert.True(t, c.Bool("data1"))
+	assert.Equal(t, 9, c.Int("data3"))
+	assert.Equal(t, "", c.String("extra"))
+	assert.NotContains(t, c.Keys(), "extra")
+}
+
+func TestOverrideOnChange(t *testing.T) {
+	c := configfile.NewMapReader(map[string]string{"data1": "a"}).Debounce(0)
+
+	ch := make(chan string, 1)
+	c.OnChange("data1", func(old, new []byte) { ch <- string(new) })
+
+	restore := c.Override("data1", "b")
+	select {
+	case v := <-ch:
+		assert.Equal(t, "b", v)
+	case <-time.After(time.Second):
+		t.Fatal("change not notified")
+	}
+
+	restore()
+	select {
+	case v := <-ch:
+		assert.Equal(t, "a", v)
+	case <-time.After(time.Second):
+		t.Fatal("change not notified")
+	}
+}
+
+func TestOverrideRestoreOrder(t *testing.T) {
+	c := configfile.NewMapReader(nil)
+
+	ra := c.Override("x", "1")
+	rb := c.Override("x", "2")
+	assert.Equal(t, "2", c.String("x"))
+	ra()
+	assert.Equal(t, "2", c.String("x"))
+	rb()
+	assert.Equal(t, "", c.String("x"))
+	assert.False(t, c.IsSet("x"))
+
+	ra = c.Override("x", "1")
+	rb = c.Override("x", "2")
+	rb()
+	assert.Equal(t, "1", c.String("x"))
+	ra()
+	assert.False(t, c.IsSet("x"))
+	ra()
+	assert.False(t, c.IsSet("x"))
+}
+
+func TestOverrideSub(t *testing.T) {
+	c := configfile.NewYAMLReaderFromReader(strings.NewReader("redis:\n  addr: a\n  db: 1\n")).
+		Fallback(configfile.NewMapReader(nil))
+
+	restore := c.Override("redis.addr", "b")
+	assert.Equal(t, "b", c.Sub("redis").String("addr"))
+	assert.Equal(t, 1, c.Sub("redis").Int("db"))
+	assert.True(t, c.Sub("redis").IsSet("addr"))
+
+	redis := c.Sub("redis").Debounce(0)
+	addr := configfile.Watch(redis, "addr", "")
+	assert.Equal(t, "b", addr.Load())
+	restoreC := c.Override("redis.addr", "c")
+	assert.Eventually(t, func() bool { return addr.Load() == "c" }, time.Second, 10*time.Millisecond)
+	restoreC()
+	restore()
+	assert.Eventually(t, func() bool { return addr.Load() == "a" }, time.Second, 10*time.Millisecond)
+
+	restore = c.Override("redis.pool.size", "5")
+	assert.Equal(t, 5, c.Sub("redis").Sub("pool").Int("size"))
+	restore()
+	assert.Equal(t, 0, c.Sub("redis").Sub("pool").Int("size"))
+}
+
+func TestWith(t *testing.T) {
+	c := configfile.NewMapReader(map[string]string{"a": "1", "b": "2"})
+	d := c.With(map[string]string{"a": "3"})
+	assert.Equal(t, 3, d.Int("a"))
+	assert.Equal(t, 2, d.Int("b"))
+	assert.Equal(t, 1, c.Int("a"))
+}
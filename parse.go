@@ -0,0 +1,91 @@
+package configfile
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var parsers sync.Map // map[reflect.Type]func(string) (T, error)
+
+// RegisterParser registers parser for type T used by Get, GetDefault, MustGet and Watch,
+// registered parser takes precedence over built-in parsing
+func RegisterParser[T any](fn func(s string) (T, error)) {
+	parsers.Store(reflect.TypeOf((*T)(nil)).Elem(), fn)
+}
+
+func parseFloat32(s string) (float32, error) {
+	f, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return 0, err
+	}
+	return float32(f), nil
+}
+
+func parseBool(s string) (bool, error) {
+	if s == "" {
+		return false, io.EOF
+	}
+	if s == "0" {
+		return false, nil
+	}
+	if strings.ToLower(s) == "false" {
+		return false, nil
+	}
+	return true, nil
+}
+
+// parse parses b as T using registered parser,
+// then the same rules as typed accessors, then encoding.TextUnmarshaler
+func parse[T any](b []byte) (T, error) {
+	var (
+		v   T
+		err error
+	)
+	s := string(b)
+	if fn, ok := parsers.Load(reflect.TypeOf((*T)(nil)).Elem()); ok {
+		return fn.(func(string) (T, error))(s)
+	}
+
+	switch p := any(&v).(type) {
+	case *string:
+		*p = s
+	case *[]byte:
+		*p = b
+	case *bool:
+		*p, err = parseBool(s)
+	case *int:
+		*p, err = strconv.Atoi(s)
+	case *int64:
+		*p, err = strconv.ParseInt(s, 10, 64)
+	case *float32:
+		*p, err = parseFloat32(s)
+	case *float64:
+		*p, err = strconv.ParseFloat(s, 64)
+	case *time.Duration:
+		*p, err = time.ParseDuration(s)
+	case encoding.TextUnmarshaler:
+		err = p.UnmarshalText(b)
+	default:
+		// pointer type which its element implements encoding.TextUnmarshaler, e.g. *big.Int
+		t := reflect.TypeOf(v)
+		if t == nil || t.Kind() != reflect.Pointer {
+			return v, fmt.Errorf("configfile: unsupported type %T", v)
+		}
+		x := reflect.New(t.Elem())
+		u, ok := x.Interface().(encoding.TextUnmarshaler)
+		if !ok {
+			return v, fmt.Errorf("configfile: unsupported type %T", v)
+		}
+		err = u.UnmarshalText(b)
+		if err == nil {
+			v = x.Interface().(T)
+		}
+	}
+	return v, err
+}
@@ -0,0 +1,65 @@
+package configfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/acoshift/configfile/internal/reader"
+)
+
+// ProfileEnv is the env name that NewProfileReader reads profile from
+const ProfileEnv = "APP_ENV"
+
+// NewProfileReader creates new config reader that layers profile overlays on top of base.
+//
+// For file base (config.yaml), it reads from config.local.yaml,
+// then config.<profile>.yaml, then config.yaml.
+// For dir base (config), it reads from config/local, then config/<profile>, then config,
+// overlay directories are not configs of the base, so Strict does not report them.
+// Missing overlays are skipped, env is the last fallback like NewReader.
+//
+// If profile is empty, it is taken from APP_ENV env
+func NewProfileReader(base, profile string) *Reader {
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+
+	var layers []*Reader
+	if stats, err := os.Stat(base); err == nil && stats.IsDir() {
+		for _, name := range profileNames(profile) {
+			dir := filepath.Join(base, name)
+			if stats, err := os.Stat(dir); err == nil && stats.IsDir() {
+				layers = append(layers, NewDirReader(dir))
+			}
+		}
+		// overlays are not configs of base
+		layers = append(layers, &Reader{r: &reader.Dir{Base: base, Skip: profileNames(profile)}, norm: NormalizeTrimNewline})
+	} else {
+		ext := filepath.Ext(base)
+		prefix := strings.TrimSuffix(base, ext)
+		for _, name := range profileNames(profile) {
+			fn := prefix + "." + name + ext
+			if _, err := os.Stat(fn); err == nil {
+				layers = append(layers, newFileReader(fn))
+			}
+		}
+		if _, err := os.Stat(base); err == nil {
+			layers = append(layers, newFileReader(base))
+		}
+	}
+	layers = append(layers, NewEnvReader())
+
+	for i := len(layers) - 1; i > 0; i-- {
+		layers[i-1].Fallback(layers[i])
+	}
+	return layers[0]
+}
+
+// profileNames returns overlay names from highest precedence
+func profileNames(profile string) []string {
+	if profile == "" || profile == "local" {
+		return []string{"local"}
+	}
+	return []string{"local", profile}
+}
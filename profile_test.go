@@ -0,0 +1,42 @@
+package configfile_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestProfileReader(t *testing.T) {
+	t.Run("File", func(t *testing.T) {
+		c := configfile.NewProfileReader("testdata/profile/config.yaml", "production")
+		assert.Equal(t, ":8080", c.String("addr"))
+		assert.Equal(t, 16, c.Int("workers"))
+		assert.Equal(t, "trace", c.String("log_level"))
+		assert.Equal(t, 1, c.Int("ONLYENV"))
+
+		c = configfile.NewProfileReader("testdata/profile/config.yaml", "staging")
+		assert.Equal(t, 4, c.Int("workers"))
+	})
+
+	t.Run("Dir", func(t *testing.T) {
+		c := configfile.NewProfileReader("testdata/profile", "production")
+		assert.Equal(t, ":8080", c.String("addr"))
+		assert.Equal(t, 16, c.Int("workers"))
+		assert.Equal(t, "localhost", c.String("db"))
+		assert.Equal(t, 1, c.Int("ONLYENV"))
+	})
+
+	t.Run("Env", func(t *testing.T) {
+		t.Setenv("APP_ENV", "production")
+
+		c := configfile.NewProfileReader("testdata/profile/config.yaml", "")
+		assert.Equal(t, 16, c.Int("workers"))
+	})
+
+	t.Run("NotExists", func(t *testing.T) {
+		c := configfile.NewProfileReader("notexists.yaml", "production")
+		assert.Equal(t, 1, c.Int("ONLYENV"))
+	})
+}
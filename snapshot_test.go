@@ -0,0 +1,59 @@
+package configfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestDirSnapshotReader(t *testing.T) {
+	testReader(t, configfile.NewDirSnapshotReader("testdata"))
+}
+
+// writeConfigMap writes dir in the same layout kubelet uses for configmap volume
+func writeConfigMap(t *testing.T, dir, version string, data map[string]string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, version), 0755))
+	for k, v := range data {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, version, k), []byte(v), 0644))
+		os.Symlink(filepath.Join("..data", k), filepath.Join(dir, k))
+	}
+
+	tmp := filepath.Join(dir, "..data_tmp")
+	require.NoError(t, os.Symlink(version, tmp))
+	require.NoError(t, os.Rename(tmp, filepath.Join(dir, "..data")))
+}
+
+func TestDirSnapshotReaderReload(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigMap(t, dir, "..v1", map[string]string{"host": "a", "port": "1"})
+
+	c := configfile.NewDirSnapshotReader(dir)
+	assert.Equal(t, "a", c.String("host"))
+	assert.Equal(t, 1, c.Int("port"))
+	assert.Equal(t, "", c.String("..data/host"))
+
+	writeConfigMap(t, dir, "..v2", map[string]string{"host": "b", "port": "2"})
+	assert.Equal(t, "a", c.String("host"), "snapshot must not change before reload")
+
+	require.NoError(t, c.Reload())
+	assert.Equal(t, "b", c.String("host"))
+	assert.Equal(t, 2, c.Int("port"))
+}
+
+func TestDirSnapshotReaderReloadError(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "host"), []byte("a"), 0644))
+
+	c := configfile.NewDirSnapshotReader(dir)
+	require.NoError(t, os.RemoveAll(dir))
+
+	assert.Error(t, c.Reload())
+	assert.Equal(t, "a", c.String("host"), "must keep last snapshot")
+}
@@ -0,0 +1,98 @@
+package configfile
+
+import (
+	"bytes"
+	"os"
+
+	"filippo.io/age"
+	"github.com/ProtonMail/go-crypto/openpgp"
+
+	"github.com/acoshift/configfile/internal/reader"
+)
+
+// SOPSMasterKey is an encrypted data key in sops file metadata
+type SOPSMasterKey = reader.SOPSMasterKey
+
+// SOPSKey decrypts sops data key,
+// implement it to use cloud kms master keys
+type SOPSKey interface {
+	DecryptDataKey(keys []SOPSMasterKey) ([]byte, error)
+}
+
+type sopsAgeKey struct {
+	ids []age.Identity
+}
+
+func (k *sopsAgeKey) DecryptDataKey(keys []SOPSMasterKey) ([]byte, error) {
+	return reader.AgeSOPSDataKey(k.ids...)(keys)
+}
+
+// NewSOPSAgeKeyFromFile creates sops key from age identities file, e.g. keys.txt
+func NewSOPSAgeKeyFromFile(filename string) (SOPSKey, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, err
+	}
+	return &sopsAgeKey{ids}, nil
+}
+
+type sopsPGPKey struct {
+	keyring openpgp.EntityList
+}
+
+func (k *sopsPGPKey) DecryptDataKey(keys []SOPSMasterKey) ([]byte, error) {
+	return reader.PGPSOPSDataKey(k.keyring)(keys)
+}
+
+// NewSOPSPGPKeyFromFile creates sops key from armored or binary pgp private key file,
+// e.g. exported with gpg --export-secret-keys, passphrase decrypts protected keys
+func NewSOPSPGPKeyFromFile(filename string, passphrase []byte) (SOPSKey, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(b))
+	if err != nil {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(b))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range keyring {
+		if e.PrivateKey != nil && e.PrivateKey.Encrypted {
+			if err := e.PrivateKey.Decrypt(passphrase); err != nil {
+				return nil, err
+			}
+		}
+		for _, sub := range e.Subkeys {
+			if sub.PrivateKey != nil && sub.PrivateKey.Encrypted {
+				if err := sub.PrivateKey.Decrypt(passphrase); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return &sopsPGPKey{keyring}, nil
+}
+
+// NewSOPSReader creates new reader from sops encrypted yaml or json file,
+// values are returned only after file mac is verified.
+// If key is nil, age identities are loaded from SOPS_AGE_KEY, SOPS_AGE_KEY_FILE
+// or sops/age/keys.txt in user config dir, and pgp data keys are decrypted
+// with gpg command (gpg-agent), which NewYAMLReader and NewJSONReader
+// also use when they read a sops encrypted file
+func NewSOPSReader(filename string, key SOPSKey) *Reader {
+	dataKey := reader.DefaultSOPSDataKey
+	if key != nil {
+		dataKey = key.DecryptDataKey
+	}
+	return &Reader{r: reader.NewSOPSFile(filename, dataKey)}
+}
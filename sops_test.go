@@ -0,0 +1,110 @@
+package configfile_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestSOPSReader(t *testing.T) {
+	key, err := configfile.NewSOPSAgeKeyFromFile("testdata/sops/key.txt")
+	require.NoError(t, err)
+
+	for _, fn := range []string{"testdata/sops/config.yaml", "testdata/sops/config.json"} {
+		t.Run(filepath.Ext(fn), func(t *testing.T) {
+			c := configfile.NewSOPSReader(fn, key)
+			testReader(t, c)
+			assert.Equal(t, "localhost:6379", c.String("redis.addr"))
+			assert.Equal(t, 1, c.Int("redis.db"))
+		})
+	}
+
+	t.Run("List", func(t *testing.T) {
+		c := configfile.NewSOPSReader("testdata/sops/config.yaml", key)
+		assert.Equal(t, "a.example.com", c.String("hosts.0"))
+		assert.Equal(t, "b.example.com", c.String("hosts.1"))
+	})
+
+	t.Run("UnencryptedSuffix", func(t *testing.T) {
+		c := configfile.NewSOPSReader("testdata/sops/partial.yaml", key)
+		assert.Equal(t, "secret", c.String("token"))
+		assert.Equal(t, 8080, c.Int("port_plain"))
+		assert.Equal(t, 1.5, c.Float64("ratio_plain"))
+		assert.True(t, c.Bool("debug_plain"))
+	})
+
+	t.Run("DefaultKey", func(t *testing.T) {
+		t.Setenv("SOPS_AGE_KEY_FILE", "testdata/sops/key.txt")
+
+		testReader(t, configfile.NewYAMLReader("testdata/sops/config.yaml"))
+		testReader(t, configfile.NewJSONReader("testdata/sops/config.json"))
+		testReader(t, configfile.NewSOPSReader("testdata/sops/config.yaml", nil))
+	})
+
+	t.Run("NoKey", func(t *testing.T) {
+		t.Setenv("SOPS_AGE_KEY_FILE", "notexists")
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+		c := configfile.NewYAMLReader("testdata/sops/config.yaml")
+		assert.Equal(t, "", c.String("data3"))
+		assert.PanicsWithError(t, "reader: sops: no age identity", func() { c.MustString("data3") })
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		b, err := os.ReadFile("testdata/sops/partial.yaml")
+		require.NoError(t, err)
+
+		fn := filepath.Join(t.TempDir(), "partial.yaml")
+		require.NoError(t, os.WriteFile(fn, []byte(strings.Replace(string(b), "port_plain: 8080", "port_plain: 9090", 1)), 0644))
+
+		c := configfile.NewSOPSReader(fn, key)
+		assert.Equal(t, "", c.String("token"), "must not return any value when mac mismatch")
+		assert.PanicsWithError(t, "reader: sops: mac mismatch", func() { c.MustString("token") })
+	})
+}
+
+func TestSOPSReaderPGP(t *testing.T) {
+	t.Run("KeyFile", func(t *testing.T) {
+		key, err := configfile.NewSOPSPGPKeyFromFile("testdata/sops/pgp.asc", nil)
+		require.NoError(t, err)
+
+		c := configfile.NewSOPSReader("testdata/sops/pgp.yaml", key)
+		assert.Equal(t, 8080, c.Int("port"))
+		assert.Equal(t, "secret", c.String("password"))
+		assert.Equal(t, "value", c.String("nested.key"))
+	})
+
+	t.Run("WrongKey", func(t *testing.T) {
+		key, err := configfile.NewSOPSAgeKeyFromFile("testdata/sops/key.txt")
+		require.NoError(t, err)
+
+		c := configfile.NewSOPSReader("testdata/sops/pgp.yaml", key)
+		assert.Equal(t, "", c.String("password"))
+	})
+
+	t.Run("GPG", func(t *testing.T) {
+		if _, err := exec.LookPath("gpg"); err != nil {
+			t.Skip("gpg not installed")
+		}
+		home, err := os.MkdirTemp("", "gnupg")
+		require.NoError(t, err)
+		defer os.RemoveAll(home)
+		t.Setenv("GNUPGHOME", home)
+		t.Setenv("SOPS_AGE_KEY_FILE", "notexists")
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		defer exec.Command("gpgconf", "--kill", "gpg-agent").Run()
+
+		out, err := exec.Command("gpg", "--batch", "--import", "testdata/sops/pgp.asc").CombinedOutput()
+		require.NoError(t, err, string(out))
+
+		c := configfile.NewYAMLReader("testdata/sops/pgp.yaml")
+		assert.Equal(t, "secret", c.String("password"))
+	})
+}
@@ -0,0 +1,161 @@
+package configfile
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/acoshift/configfile/internal/reader"
+)
+
+// UnknownKey is a key found in config sources that never read nor declared
+type UnknownKey struct {
+	Key string
+
+	// Suggestion is the closest known name, empty if nothing is close
+	Suggestion string
+}
+
+// UnknownKeysError is returned from Strict when config sources contain unknown keys
+type UnknownKeysError struct {
+	Keys []UnknownKey
+}
+
+func (e *UnknownKeysError) Error() string {
+	xs := make([]string, len(e.Keys))
+	for i, k := range e.Keys {
+		xs[i] = k.Key
+		if k.Suggestion != "" {
+			xs[i] += " (did you mean " + k.Suggestion + "?)"
+		}
+	}
+	return "configfile: unknown keys: " + strings.Join(xs, ", ")
+}
+
+// Declare marks names as known to Strict without reading them,
+// for configs that are read lazily after startup
+func (r *Reader) Declare(names ...string) *Reader {
+	for _, name := range names {
+		r.record(name)
+	}
+	return r
+}
+
+// record marks name as known, names read from Sub are recorded with prefix in parent reader
+func (r *Reader) record(name string) {
+	if r.parent != nil {
+		r.parent.record(r.prefix + "." + name)
+		return
+	}
+	k := normalizeKey(name)
+	if _, ok := r.known.Load(k); !ok {
+		r.known.Store(k, name)
+	}
+}
+
+// normalizeKey makes names from different sources comparable (redis.addr, redis/addr, redis_addr, redis-addr)
+func normalizeKey(name string) string {
+	return strings.Map(func(c rune) rune {
+		switch c {
+		case '/', '_', '-':
+			return '.'
+		}
+		return c
+	}, strings.ToLower(name))
+}
+
+// strictSource reports whether keys of source are checked by Strict,
+// env, flags and args contain unrelated keys so they are skipped
+func strictSource(r intlReader) bool {
+	if c, ok := r.(*reader.Cached); ok {
+		return strictSource(c.Source().(intlReader))
+	}
+	switch r.(type) {
+	case *reader.YAML, *reader.JSON, *reader.DotEnv, *reader.Dir, *reader.DirSnapshot, *reader.FS, *reader.SOPS, *reader.Kubernetes:
+		return true
+	}
+	return false
+}
+
+// UnknownKeys returns keys in file and dir sources of the chain
+// that never read nor declared, call after all configs are read
+func (r *Reader) UnknownKeys() []UnknownKey {
+	known := make(map[string]string)
+	for p := r; p != nil; p = p.fallback {
+		p.known.Range(func(k, v interface{}) bool {
+			known[k.(string)] = v.(string)
+			return true
+		})
+	}
+
+	var xs []UnknownKey
+	seen := make(map[string]bool)
+	for p := r; p != nil; p = p.fallback {
+		if !strictSource(p.r) {
+			continue
+		}
+		l, ok := p.r.(keyLister)
+		if !ok {
+			continue
+		}
+		keys, _ := l.Keys()
+		for _, key := range keys {
+			k := normalizeKey(key)
+			if _, ok := known[k]; ok || seen[key] {
+				continue
+			}
+			seen[key] = true
+			xs = append(xs, UnknownKey{Key: key, Suggestion: suggest(k, known)})
+		}
+	}
+	sort.Slice(xs, func(i, j int) bool { return xs[i].Key < xs[j].Key })
+	return xs
+}
+
+// Strict returns *UnknownKeysError when file and dir sources in the chain contain keys
+// that never read nor declared, call after startup read all configs
+func (r *Reader) Strict() error {
+	keys := r.UnknownKeys()
+	if len(keys) == 0 {
+		return nil
+	}
+	return &UnknownKeysError{Keys: keys}
+}
+
+// suggest returns the known name closest to k
+func suggest(k string, known map[string]string) string {
+	best, bestDist := "", len(k)/3+1
+	for nk, name := range known {
+		d := distance(k, nk)
+		if d < bestDist || (d == bestDist && best != "" && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// distance returns levenshtein distance between a and b
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if d := prev[j] + 1; d < cur[j] {
+				cur[j] = d
+			}
+			if d := cur[j-1] + 1; d < cur[j] {
+				cur[j] = d
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
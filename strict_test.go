@@ -0,0 +1,74 @@
+package configfile_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestStrict(t *testing.T) {
+	c := configfile.NewYAMLReaderFromReader(strings.NewReader(`
+port: 8080
+redis_adr: localhost:6379
+redis:
+  db: 1
+timeout: 5s
+`)).Fallback(configfile.NewEnvReader())
+
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.Equal(t, "", c.String("redis_addr"))
+	assert.Equal(t, 1, c.Sub("redis").Int("db"))
+	c.Declare("timeout")
+
+	err := c.Strict()
+	require.Error(t, err)
+
+	var uerr *configfile.UnknownKeysError
+	require.True(t, errors.As(err, &uerr))
+	assert.Equal(t, []configfile.UnknownKey{{Key: "redis_adr", Suggestion: "redis_addr"}}, uerr.Keys)
+	assert.EqualError(t, err, "configfile: unknown keys: redis_adr (did you mean redis_addr?)")
+
+	c.Declare("redis.adr")
+	assert.NoError(t, c.Strict())
+}
+
+func TestStrictDir(t *testing.T) {
+	c := configfile.NewDirReader("testdata")
+	c.String("data1")
+
+	keys := c.UnknownKeys()
+	assert.Contains(t, keys, configfile.UnknownKey{Key: "data2", Suggestion: "data1"})
+	for _, k := range keys {
+		assert.NotEqual(t, "data1", k.Key)
+	}
+}
+
+func TestStrictProfileDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, value := range map[string]string{
+		"addr":               ":8080",
+		"workers":            "1",
+		"redis/addr":         "localhost:6379",
+		"production/workers": "8",
+		"local/db":           "local",
+	} {
+		fn := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(fn), 0755))
+		require.NoError(t, os.WriteFile(fn, []byte(value), 0644))
+	}
+
+	c := configfile.NewProfileReader(dir, "production")
+	assert.Equal(t, ":8080", c.String("addr"))
+	assert.Equal(t, 8, c.Int("workers"))
+	assert.Equal(t, "local", c.String("db"))
+	assert.Equal(t, "localhost:6379", c.Sub("redis").String("addr"))
+	assert.NoError(t, c.Strict())
+	assert.Equal(t, "", c.String("production/workers"), "overlay must not be read from base")
+}
@@ -0,0 +1,20 @@
+package configfile
+
+import "github.com/acoshift/configfile/internal/reader"
+
+// Sub returns reader scoped to prefix, e.g. Sub("redis").String("addr") reads
+// redis.addr from yaml and json, redis/addr or redis_addr from dir,
+// REDIS_ADDR from env and --redis-addr from flags and args
+func (r *Reader) Sub(prefix string) *Reader {
+	var root, last *Reader
+	for p := r; p != nil; p = p.fallback {
+		s := &Reader{r: reader.NewSub(p.r, prefix), decrypter: p.decrypter, norm: p.norm, empty: r.empty, parent: p, prefix: prefix}
+		if root == nil {
+			root = s
+		} else {
+			last.fallback = s
+		}
+		last = s
+	}
+	return root
+}
@@ -0,0 +1,113 @@
+package configfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+func testSubReader(t *testing.T, c *configfile.Reader) {
+	t.Helper()
+
+	redis := c.Sub("redis")
+	assert.Equal(t, "localhost:6379", redis.String("addr"))
+	assert.Equal(t, 1, redis.Int("db"))
+	assert.Equal(t, "", redis.String("notfound"))
+	assert.Subset(t, redis.Keys(), []string{"addr", "db"})
+}
+
+func TestSub(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		c := configfile.NewYAMLReader("testdata/sub/config.yaml")
+		testSubReader(t, c)
+		assert.Equal(t, "mymaster", c.Sub("redis").Sub("sentinel").String("master"))
+		assert.Equal(t, "b.example.com", c.String("hosts.1"))
+	})
+
+	t.Run("YAMLMerge", func(t *testing.T) {
+		c := configfile.NewYAMLReaderFromReader(strings.NewReader(`
+base: &base
+  x: base
+  y: base
+  nested:
+    a: 1
+extra: &extra
+  y: extra
+  z: extra
+single:
+  x: explicit
+  <<: *base
+list:
+  <<: [*base, *extra]
+  nested:
+    b: 2
+`))
+		assert.Equal(t, "explicit", c.String("single.x"), "explicit key before merge must win")
+		assert.Equal(t, "base", c.String("single.y"))
+		assert.Equal(t, 1, c.Int("single.nested.a"))
+
+		list := c.Sub("list")
+		assert.Equal(t, "base", list.String("x"))
+		assert.Equal(t, "base", list.String("y"), "first merged mapping must win")
+		assert.Equal(t, "extra", list.String("z"))
+		assert.Equal(t, 2, list.Int("nested.b"))
+		assert.Equal(t, "", list.String("nested.a"), "merge must not go deeper than top level keys")
+		assert.ElementsMatch(t, []string{"x", "y", "z", "nested.b"}, list.Keys())
+	})
+
+	t.Run("Dir", func(t *testing.T) {
+		testSubReader(t, configfile.NewDirReader("testdata/sub"))
+	})
+
+	t.Run("Env", func(t *testing.T) {
+		t.Setenv("REDIS_ADDR", "localhost:6379")
+		t.Setenv("REDIS_DB", "1")
+
+		testSubReader(t, configfile.NewEnvReader())
+	})
+
+	t.Run("Args", func(t *testing.T) {
+		testSubReader(t, configfile.NewArgsReader([]string{"--redis-addr=localhost:6379", "--redis-db=1"}))
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		t.Setenv("REDIS_PASSWORD", "secret")
+
+		redis := configfile.NewReader("testdata/sub/config.yaml").Sub("redis")
+		assert.Equal(t, "localhost:6379", redis.String("addr"))
+		assert.Equal(t, "secret", redis.String("password"))
+	})
+}
+
+func TestSubOnChange(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "redis", "sentinel"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "redis", "addr"), []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "redis", "sentinel", "master"), []byte("a"), 0644))
+
+	c := configfile.NewDirReader(dir).Debounce(0)
+	redis := c.Sub("redis").Debounce(0)
+	addr := configfile.Watch(redis, "addr", "")
+	sentinel := c.Sub("redis").Sub("sentinel").Debounce(0)
+	master := make(chan string, 1)
+	sentinel.OnChange("master", func(_, new []byte) { master <- string(new) })
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "redis", "addr"), []byte("b"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "redis", "sentinel", "master"), []byte("b"), 0644))
+	require.NoError(t, c.Reload())
+
+	assert.Eventually(t, func() bool { return addr.Load() == "b" }, time.Second, 10*time.Millisecond)
+	select {
+	case v := <-master:
+		assert.Equal(t, "b", v)
+	case <-time.After(time.Second):
+		t.Fatal("nested sub not notified")
+	}
+}
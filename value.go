@@ -0,0 +1,41 @@
+package configfile
+
+import "sync/atomic"
+
+// Value is a live config value that refreshes when sources reload
+type Value[T any] struct {
+	p atomic.Pointer[T]
+}
+
+// Load returns current value
+func (v *Value[T]) Load() T {
+	return *v.p.Load()
+}
+
+// Watch returns live value of name parsed as T, supports the same types as Get.
+// The value is def when config not found,
+// and keeps the last good value when new value can not be parsed
+func Watch[T any](r *Reader, name string, def T) *Value[T] {
+	var v Value[T]
+
+	x := def
+	if b, err := r.read(name); err == nil {
+		if p, err := parseValue[T](r, b); err == nil {
+			x = p
+		}
+	}
+	v.p.Store(&x)
+
+	r.OnChange(name, func(_, b []byte) {
+		if b == nil {
+			v.p.Store(&def)
+			return
+		}
+		p, err := parseValue[T](r, b)
+		if err != nil {
+			return
+		}
+		v.p.Store(&p)
+	})
+	return &v
+}
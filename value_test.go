@@ -0,0 +1,54 @@
+package configfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "workers")
+	require.NoError(t, os.WriteFile(fn, []byte("8"), 0644))
+
+	c := configfile.NewDirReader(dir).Debounce(0)
+	workers := configfile.Watch[int](c, "workers", 4)
+	timeout := configfile.Watch(c, "timeout", 3*time.Second)
+	assert.Equal(t, 8, workers.Load())
+	assert.Equal(t, 3*time.Second, timeout.Load())
+
+	reload := func(v string) {
+		t.Helper()
+
+		require.NoError(t, os.WriteFile(fn, []byte(v), 0644))
+		require.NoError(t, c.Reload())
+	}
+
+	reload("16")
+	assert.Eventually(t, func() bool { return workers.Load() == 16 }, time.Second, time.Millisecond)
+
+	reload("not a number")
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 16, workers.Load(), "must keep last good value")
+
+	require.NoError(t, os.Remove(fn))
+	require.NoError(t, c.Reload())
+	assert.Eventually(t, func() bool { return workers.Load() == 4 }, time.Second, time.Millisecond)
+}
+
+func TestWatchTypes(t *testing.T) {
+	c := configfile.NewDirReader("testdata")
+	assert.True(t, configfile.Watch(c, "data1", false).Load())
+	assert.Equal(t, int64(9), configfile.Watch(c, "data3", int64(0)).Load())
+	assert.Equal(t, float32(1.25), configfile.Watch(c, "data7", float32(0)).Load())
+	assert.Equal(t, 1.25, configfile.Watch(c, "data7", 0.0).Load())
+	assert.Equal(t, "3m5s", configfile.Watch(c, "data5", "").Load())
+	assert.Equal(t, []byte("9"), configfile.Watch[[]byte](c, "data3", nil).Load())
+	assert.Equal(t, 5, configfile.Watch(c, "empty", 5).Load())
+}
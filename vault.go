@@ -0,0 +1,29 @@
+package configfile
+
+import (
+	"os"
+
+	"github.com/acoshift/configfile/internal/reader"
+)
+
+// VaultOptions configures NewVaultReader
+type VaultOptions = reader.VaultOptions
+
+// NewVaultReader creates new reader from vault kv secrets engine,
+// fields of secret at opt.Path are config names.
+// Empty Address, Token and Namespace are taken from VAULT_ADDR, VAULT_TOKEN
+// and VAULT_NAMESPACE env.
+// Secrets are cached for their lease duration or opt.TTL,
+// call Reload to fetch cached secrets again
+func NewVaultReader(opt VaultOptions) *Reader {
+	if opt.Address == "" {
+		opt.Address = os.Getenv("VAULT_ADDR")
+	}
+	if opt.Token == "" && opt.RoleID == "" {
+		opt.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if opt.Namespace == "" {
+		opt.Namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	return &Reader{r: reader.NewVault(opt)}
+}
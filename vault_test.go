@@ -0,0 +1,264 @@
+package configfile_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+// fakeVault mimics vault http api for kv v1 (mount kv), kv v2 (mount secret) and approle login
+type fakeVault struct {
+	mu      sync.Mutex
+	data    map[string]map[string]interface{}
+	tokens  map[string]bool
+	logins  int32
+	reads   int32
+	lease   int
+	counter int
+	fail    bool
+	delay   time.Duration
+}
+
+func newFakeVault(t *testing.T) (*fakeVault, *httptest.Server) {
+	v := &fakeVault{
+		data:   make(map[string]map[string]interface{}),
+		tokens: map[string]bool{"root": true},
+	}
+	srv := httptest.NewServer(v)
+	t.Cleanup(srv.Close)
+	return v, srv
+}
+
+func (v *fakeVault) set(p string, d map[string]interface{}) {
+	v.mu.Lock()
+	v.data[p] = d
+	v.mu.Unlock()
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	p := strings.TrimPrefix(r.URL.Path, "/v1/")
+	if p == "auth/approle/login" {
+		var req struct {
+			RoleID   string `json:"role_id"`
+			SecretID string `json:"secret_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.RoleID != "role" || req.SecretID != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+			return
+		}
+		atomic.AddInt32(&v.logins, 1)
+		v.counter++
+		token := fmt.Sprintf("approle-%d", v.counter)
+		v.tokens[token] = true
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": token, "lease_duration": 3600},
+		})
+		return
+	}
+
+	if !v.tokens[r.Header.Get("X-Vault-Token")] {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+	if ns := r.Header.Get("X-Vault-Namespace"); ns != "" {
+		p = ns + "/" + p
+	}
+	atomic.AddInt32(&v.reads, 1)
+	time.Sleep(v.delay)
+	if v.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"errors":["internal error"]}`))
+		return
+	}
+
+	d, ok := v.data[p]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[]}`))
+		return
+	}
+	if strings.Contains(p, "secret/data/") {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"lease_duration": 0,
+			"data":           map[string]interface{}{"data": d, "metadata": map[string]interface{}{"version": 1}},
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"lease_duration": v.lease, "data": d})
+}
+
+func TestVaultReaderV2(t *testing.T) {
+	v, srv := newFakeVault(t)
+	v.set("secret/data/app", map[string]interface{}{
+		"redis_addr": "localhost:6379",
+		"port":       8080,
+		"debug":      true,
+		"db":         map[string]interface{}{"host": "db"},
+	})
+	v.set("secret/data/app/db", map[string]interface{}{"password": "pass"})
+
+	c := configfile.NewVaultReader(configfile.VaultOptions{Address: srv.URL, Token: "root", Path: "app"})
+	assert.Equal(t, "localhost:6379", c.String("redis_addr"))
+	assert.Equal(t, 8080, c.Int("port"))
+	assert.True(t, c.Bool("debug"))
+	assert.Equal(t, "db", c.String("db.host"))
+	assert.Equal(t, "pass", c.String("db/password"))
+	assert.Equal(t, "", c.String("notfound"))
+	assert.Equal(t, "", c.String("notfound/password"))
+	assert.Equal(t, []string{"db.host", "debug", "port", "redis_addr"}, c.Keys())
+	assert.Equal(t, "db", c.Sub("db").String("host"))
+
+	reads := atomic.LoadInt32(&v.reads)
+	c.String("redis_addr")
+	assert.Equal(t, reads, atomic.LoadInt32(&v.reads), "should read from cache")
+
+	v.set("secret/data/app", map[string]interface{}{"redis_addr": "redis:6379"})
+	assert.Equal(t, "localhost:6379", c.String("redis_addr"))
+	require.NoError(t, c.Reload())
+	assert.Equal(t, "redis:6379", c.String("redis_addr"))
+}
+
+func TestVaultReaderV1Lease(t *testing.T) {
+	v, srv := newFakeVault(t)
+	v.lease = 3600
+	v.set("kv/app", map[string]interface{}{"key": "a"})
+
+	c := configfile.NewVaultReader(configfile.VaultOptions{
+		Address: srv.URL,
+		Token:   "root",
+		Mount:   "kv",
+		Version: 1,
+		Path:    "app",
+		TTL:     time.Millisecond,
+	})
+	assert.Equal(t, "a", c.String("key"))
+
+	v.set("kv/app", map[string]interface{}{"key": "b"})
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, "a", c.String("key"), "lease should take precedence over ttl")
+}
+
+func TestVaultReaderTTL(t *testing.T) {
+	v, srv := newFakeVault(t)
+	v.set("secret/data/app", map[string]interface{}{"key": "a"})
+
+	c := configfile.NewVaultReader(configfile.VaultOptions{
+		Address: srv.URL,
+		Token:   "root",
+		Path:    "app",
+		TTL:     10 * time.Millisecond,
+	})
+	assert.Equal(t, "a", c.String("key"))
+
+	v.set("secret/data/app", map[string]interface{}{"key": "b"})
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, "b", c.String("key"))
+}
+
+func TestVaultReaderError(t *testing.T) {
+	v, srv := newFakeVault(t)
+	v.set("secret/data/app", map[string]interface{}{"key": "a"})
+	v.fail = true
+
+	c := configfile.NewVaultReader(configfile.VaultOptions{
+		Address: srv.URL,
+		Token:   "root",
+		Path:    "app",
+	})
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, "", c.String("key"))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&v.reads), "must cache error until retry")
+
+	v.mu.Lock()
+	v.fail = false
+	v.mu.Unlock()
+
+	require.NoError(t, c.Reload())
+	assert.Equal(t, "a", c.String("key"))
+}
+
+func TestVaultReaderConcurrent(t *testing.T) {
+	v, srv := newFakeVault(t)
+	v.set("secret/data/app", map[string]interface{}{"password": "secret"})
+	v.delay = 50 * time.Millisecond
+
+	c := configfile.NewVaultReader(configfile.VaultOptions{
+		Address: srv.URL,
+		Token:   "root",
+		Path:    "app",
+	})
+
+	var wg sync.WaitGroup
+	xs := make([]string, 5)
+	for i := range xs {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			xs[i] = c.StringDefault("password", "DEFAULT")
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []string{"secret", "secret", "secret", "secret", "secret"}, xs, "reads must wait for the first fetch")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&v.reads))
+}
+
+func TestVaultReaderAppRole(t *testing.T) {
+	v, srv := newFakeVault(t)
+	v.set("team/secret/data/app", map[string]interface{}{"key": "a"})
+
+	c := configfile.NewVaultReader(configfile.VaultOptions{
+		Address:   srv.URL,
+		RoleID:    "role",
+		SecretID:  "secret",
+		Namespace: "team",
+		Path:      "app",
+	})
+	assert.Equal(t, "a", c.String("key"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&v.logins))
+
+	// revoke token
+	v.mu.Lock()
+	v.tokens = map[string]bool{}
+	v.mu.Unlock()
+
+	require.NoError(t, c.Reload())
+	assert.Equal(t, "a", c.String("key"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&v.logins))
+
+	bad := configfile.NewVaultReader(configfile.VaultOptions{
+		Address:  srv.URL,
+		RoleID:   "role",
+		SecretID: "wrong",
+		Path:     "app",
+	})
+	assert.Equal(t, "", bad.String("key"))
+	assert.Panics(t, func() { bad.MustString("key") })
+}
+
+func TestVaultReaderFallback(t *testing.T) {
+	_, srv := newFakeVault(t)
+
+	c := configfile.NewVaultReader(configfile.VaultOptions{Address: srv.URL, Token: "invalid", Path: "app"}).
+		Fallback(configfile.NewMapReader(map[string]string{"key": "fallback"}))
+	assert.Equal(t, "fallback", c.String("key"))
+}
@@ -0,0 +1,247 @@
+package configfile
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultDebounce = 100 * time.Millisecond
+
+// watcher tracks values of subscribed names,
+// changes are detected after reload and delivered on its own goroutine
+type watcher struct {
+	r *Reader
+
+	mu       sync.Mutex
+	debounce time.Duration
+	subs     map[string][]func(old, new []byte)
+	last     map[string][]byte
+	timer    *time.Timer
+
+	// events is created with first subscriber, nil after close
+	events chan func()
+
+	// checkMu runs checks one at a time, so changes are delivered in order
+	checkMu sync.Mutex
+
+	// errs are called when a remote watch fails
+	errs []func(err error)
+}
+
+func (r *Reader) watch() *watcher {
+	r.mu.Lock()
+	w := r.w
+	if w == nil {
+		w = &watcher{
+			r:        r,
+			debounce: defaultDebounce,
+			subs:     make(map[string][]func(old, new []byte)),
+			last:     make(map[string][]byte),
+		}
+		r.w = w
+	}
+	r.mu.Unlock()
+
+	// reader from Sub is notified by its parent
+	for c := r; c.parent != nil && c.parent.addChild(c); c = c.parent {
+	}
+	return w
+}
+
+func deliver(events <-chan func()) {
+	for fn := range events {
+		fn()
+	}
+}
+
+// trigger schedules a check, triggers within debounce duration are merged
+func (w *watcher) trigger() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.debounce <= 0 {
+		go w.check()
+		return
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(w.debounce, w.check)
+}
+
+func (w *watcher) check() {
+	w.checkMu.Lock()
+	defer w.checkMu.Unlock()
+
+	w.mu.Lock()
+	names := make([]string, 0, len(w.subs))
+	for name := range w.subs {
+		names = append(names, name)
+	}
+	w.mu.Unlock()
+
+	// read without lock, remote sources may fetch
+	values := make(map[string][]byte, len(names))
+	for _, name := range names {
+		values[name], _ = w.r.read(name)
+	}
+
+	var events []func()
+
+	w.mu.Lock()
+	ch := w.events
+	for _, name := range names {
+		fns, ok := w.subs[name]
+		if !ok {
+			// removed by close
+			continue
+		}
+		old, p := w.last[name], values[name]
+		if bytes.Equal(old, p) && (old == nil) == (p == nil) {
+			continue
+		}
+		w.last[name] = p
+
+		for _, fn := range fns {
+			fn := fn
+			events = append(events, func() { fn(old, p) })
+		}
+	}
+	w.mu.Unlock()
+
+	for _, fn := range events {
+		ch <- fn
+	}
+}
+
+// close stops delivery goroutine and pending check
+func (w *watcher) close() {
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.subs = make(map[string][]func(old, new []byte))
+	w.last = make(map[string][]byte)
+	ch := w.events
+	w.events = nil
+	w.mu.Unlock()
+
+	// wait for running check to finish sending
+	w.checkMu.Lock()
+	if ch != nil {
+		close(ch)
+	}
+	w.checkMu.Unlock()
+}
+
+// OnChange calls fn when value of name changes after sources reload,
+// old or new is nil when config not found.
+// fn is called on a dedicated goroutine, one call at a time,
+// the goroutine runs until Close
+func (r *Reader) OnChange(name string, fn func(old, new []byte)) {
+	w := r.watch()
+	p, _ := r.read(name)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.events == nil {
+		w.events = make(chan func(), 64)
+		go deliver(w.events)
+	}
+
+	if _, ok := w.subs[name]; !ok {
+		w.last[name] = p
+	}
+	w.subs[name] = append(w.subs[name], fn)
+}
+
+// Close removes all OnChange subscribers and stops their delivery goroutine,
+// values from Watch stop updating
+func (r *Reader) Close() error {
+	r.mu.Lock()
+	w := r.w
+	r.mu.Unlock()
+	if w != nil {
+		w.close()
+	}
+	return nil
+}
+
+// Debounce sets the duration to wait for more reloads before checking for changes,
+// default is 100ms, zero checks right after each reload
+func (r *Reader) Debounce(d time.Duration) *Reader {
+	w := r.watch()
+
+	w.mu.Lock()
+	w.debounce = d
+	w.mu.Unlock()
+	return r
+}
+
+// ReloadEvery reloads sources every d until stop is called,
+// subscribers registered with OnChange get notified when values change
+func (r *Reader) ReloadEvery(d time.Duration) (stop func()) {
+	t := time.NewTicker(d)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-t.C:
+				r.Reload()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.Stop()
+			close(done)
+		})
+	}
+}
+
+// remoteWatcher is a source that pushes changes, e.g. consul blocking queries
+type remoteWatcher interface {
+	Watch(ctx context.Context, changed func(), failed func(err error))
+}
+
+// WatchRemote watches sources in the chain that can push changes (consul, etcd, kubernetes)
+// until stop is called, subscribers registered with OnChange get notified when values change.
+// Failed watches are retried with backoff and reported to OnWatchError
+func (r *Reader) WatchRemote() (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	for p := r; p != nil; p = p.fallback {
+		if w, ok := p.r.(remoteWatcher); ok {
+			go w.Watch(ctx, r.changed, r.watchFailed)
+		}
+	}
+	return cancel
+}
+
+// OnWatchError calls fn each time a remote watch started by WatchRemote fails,
+// the last values are served until the watch recovers
+func (r *Reader) OnWatchError(fn func(err error)) {
+	w := r.watch()
+
+	w.mu.Lock()
+	w.errs = append(w.errs, fn)
+	w.mu.Unlock()
+}
+
+func (r *Reader) watchFailed(err error) {
+	w := r.watch()
+
+	w.mu.Lock()
+	fns := w.errs
+	w.mu.Unlock()
+
+	for _, fn := range fns {
+		fn(err)
+	}
+}
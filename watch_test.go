@@ -0,0 +1,172 @@
+package configfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+type change struct {
+	old, new string
+	found    bool
+}
+
+func TestOnChange(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(fn, []byte("rate_limit: 10\nlog_level: info\n"), 0644))
+
+	c := configfile.NewYAMLReader(fn).Debounce(0)
+
+	changes := make(chan change, 10)
+	c.OnChange("rate_limit", func(old, new []byte) {
+		changes <- change{string(old), string(new), new != nil}
+	})
+	c.OnChange("log_level", func(old, new []byte) {
+		t.Error("log_level must not change")
+	})
+
+	require.NoError(t, c.Reload())
+	select {
+	case x := <-changes:
+		t.Fatalf("unexpected change %v", x)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(fn, []byte("rate_limit: 20\nlog_level: info\n"), 0644))
+	require.NoError(t, c.Reload())
+	select {
+	case x := <-changes:
+		assert.Equal(t, change{"10", "20", true}, x)
+	case <-time.After(time.Second):
+		t.Fatal("change not delivered")
+	}
+
+	require.NoError(t, os.WriteFile(fn, []byte("log_level: info\n"), 0644))
+	require.NoError(t, c.Reload())
+	select {
+	case x := <-changes:
+		assert.Equal(t, change{"20", "", false}, x)
+	case <-time.After(time.Second):
+		t.Fatal("change not delivered")
+	}
+}
+
+func TestOnChangeDebounce(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "workers")
+	require.NoError(t, os.WriteFile(fn, []byte("1"), 0644))
+
+	c := configfile.NewDirReader(dir).Debounce(20 * time.Millisecond)
+
+	changes := make(chan change, 10)
+	c.OnChange("workers", func(old, new []byte) {
+		changes <- change{string(old), string(new), new != nil}
+	})
+
+	for _, v := range []string{"2", "3", "4"} {
+		require.NoError(t, os.WriteFile(fn, []byte(v), 0644))
+		c.Reload()
+	}
+
+	select {
+	case x := <-changes:
+		assert.Equal(t, change{"1", "4", true}, x)
+	case <-time.After(time.Second):
+		t.Fatal("change not delivered")
+	}
+	select {
+	case x := <-changes:
+		t.Fatalf("reloads must be merged, got %v", x)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestReloadEvery(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "workers")
+	require.NoError(t, os.WriteFile(fn, []byte("1"), 0644))
+
+	c := configfile.NewDirSnapshotReader(dir).Debounce(0)
+	stop := c.ReloadEvery(5 * time.Millisecond)
+	defer stop()
+
+	changes := make(chan change, 10)
+	c.OnChange("workers", func(old, new []byte) {
+		changes <- change{string(old), string(new), new != nil}
+	})
+	require.NoError(t, os.WriteFile(fn, []byte("2"), 0644))
+
+	select {
+	case x := <-changes:
+		assert.Equal(t, change{"1", "2", true}, x)
+	case <-time.After(time.Second):
+		t.Fatal("change not delivered")
+	}
+}
+
+func TestOnChangeOrder(t *testing.T) {
+	c := configfile.NewMapReader(nil).Debounce(0)
+
+	changes := make(chan change, 100)
+	c.OnChange("n", func(old, new []byte) {
+		changes <- change{string(old), string(new), new != nil}
+	})
+
+	for i := 1; i <= 20; i++ {
+		c.Override("n", strconv.Itoa(i))
+	}
+
+	last := ""
+	for last != "20" {
+		select {
+		case x := <-changes:
+			assert.Equal(t, last, x.old, "changes must be delivered in order")
+			last = x.new
+		case <-time.After(time.Second):
+			t.Fatal("change not delivered")
+		}
+	}
+}
+
+func TestClose(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	c := configfile.NewMapReader(map[string]string{"n": "1"}).Debounce(0)
+	assert.Equal(t, before, runtime.NumGoroutine(), "debounce must not start goroutine")
+
+	called := make(chan struct{}, 10)
+	c.OnChange("n", func(_, _ []byte) { called <- struct{}{} })
+	require.NoError(t, c.Close())
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i > 100 {
+			t.Fatal("delivery goroutine not stopped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	c.Override("n", "2")
+	select {
+	case <-called:
+		t.Fatal("closed reader must not notify")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// subscribe again after close
+	c.OnChange("n", func(_, _ []byte) { called <- struct{}{} })
+	c.Override("n", "3")
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("change not delivered")
+	}
+	c.Close()
+}
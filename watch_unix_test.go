@@ -0,0 +1,60 @@
+//go:build unix
+
+package configfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acoshift/configfile"
+)
+
+func TestOnChangeSlowRead(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "slow")
+	require.NoError(t, os.WriteFile(fn, []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other"), []byte("x"), 0644))
+
+	c := configfile.NewDirReader(dir).Debounce(0)
+	defer c.Close()
+	changes := make(chan string, 1)
+	c.OnChange("slow", func(_, new []byte) { changes <- string(new) })
+
+	// reading a fifo blocks until it is written
+	require.NoError(t, os.Remove(fn))
+	require.NoError(t, syscall.Mkfifo(fn, 0644))
+	unblock := func() {
+		f, err := os.OpenFile(fn, os.O_WRONLY, 0)
+		require.NoError(t, err)
+		f.WriteString("b")
+		f.Close()
+	}
+	c.Reload()
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		c.OnChange("other", func(_, _ []byte) {})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		unblock()
+		t.Fatal("OnChange blocked by slow read")
+	}
+	unblock()
+
+	select {
+	case v := <-changes:
+		assert.Equal(t, "b", v)
+	case <-time.After(time.Second):
+		t.Fatal("change not delivered")
+	}
+}